@@ -0,0 +1,89 @@
+package main
+
+import "sort"
+
+//-----------------------------------------------------------------------------------
+// Abilities: when a pac should spend its cooldown on SPEED or SWITCH outside of a fight
+//-----------------------------------------------------------------------------------
+
+// AbilityWeights tune how eager pacs are to use their ability
+type AbilityWeights struct {
+	// farmValue is the least discounted pellet value the route ahead has to hold for SPEED to pay off while farming
+	farmValue float64
+	// reserveDistance is how close, in cells, an enemy in sight has to be for a pac to keep its cooldown for a defensive SWITCH
+	reserveDistance int
+	// counterShare is the share of the enemy pacs that has to be of one type for a pac to switch ahead of time to the type beating it
+	counterShare float64
+	// counterValue is what switching ahead of time to the type beating the enemy's is worth, in pellets, were every enemy pac of that type.
+	// A pac only switches when that's worth more than speeding up would be
+	counterValue float64
+	// countersPerTurn is how many pacs may switch ahead of time on the same turn
+	countersPerTurn int
+}
+
+var defaultAbilityWeights = AbilityWeights{farmValue: 4, reserveDistance: 8, counterShare: 0.5, counterValue: 8, countersPerTurn: 1}
+
+// AbilityPlanner decides when my pacs use their ability while they're not fighting
+type AbilityPlanner struct {
+	weights   AbilityWeights
+	distances *DistanceTable
+	// round is the turn the pacs in counters switched on
+	round int
+	// counters is how many pacs switched ahead of time this round
+	counters int
+}
+
+func newAbilityPlanner(distances *DistanceTable, weights AbilityWeights) *AbilityPlanner {
+	return &AbilityPlanner{weights: weights, distances: distances}
+}
+
+// plan returns the ability pac should use this turn, if any, given the route it's about to walk and how many enemy pacs of each type
+// have been seen. A pac with an enemy in sight close by keeps its cooldown to switch out of trouble. Otherwise, it switches to counter
+// the most common enemy type, unless another pac already did this turn or speeding up along its route is worth more, or it speeds up
+// along a route rich in pellets
+func (planner *AbilityPlanner) plan(pac Pac, gameData GameData, route Route, enemyTypes map[PacType]int) (Action, bool) {
+	if gameData.round != planner.round {
+		planner.round, planner.counters = gameData.round, 0
+	}
+	if pac.abilityCooldown > 0 || pac.typeID == deadTypeID {
+		return nil, false
+	}
+	for _, enemy := range gameData.visiblePacs {
+		if !enemy.mine && enemy.typeID != deadTypeID && planner.distances.distance(pac.pos, enemy.pos) <= planner.weights.reserveDistance {
+			return nil, false
+		}
+	}
+
+	speedValue := planner.weights.farmValue
+	if route.value > speedValue {
+		speedValue = route.value
+	}
+	if common, share, found := planner.commonType(enemyTypes); found && planner.counters < planner.weights.countersPerTurn {
+		if counter := getWinningTypeId(common); pac.typeID != counter && planner.weights.counterValue*share > speedValue {
+			planner.counters++
+			return Switch{pac.id, counter, "COUNTER"}, true
+		}
+	}
+	if pac.speedTurnsLeft == 0 && len(route.steps) > 1 && route.value >= planner.weights.farmValue {
+		return Speed{pac.id, "FARM"}, true
+	}
+	return nil, false
+}
+
+// commonType returns the enemy type that makes up more than counterShare of enemyTypes, if there's one, along with its share
+func (planner *AbilityPlanner) commonType(enemyTypes map[PacType]int) (PacType, float64, bool) {
+	total := 0
+	types := make([]PacType, 0, len(enemyTypes))
+	for typeID, count := range enemyTypes {
+		total += count
+		types = append(types, typeID)
+	}
+	// map iteration order is random, so go through types in order to stay deterministic
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	for _, typeID := range types {
+		if share := float64(enemyTypes[typeID]) / float64(total); typeID != deadTypeID && share > planner.weights.counterShare {
+			return typeID, share, true
+		}
+	}
+	return "", 0, false
+}
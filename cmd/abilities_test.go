@@ -0,0 +1,130 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAbilityPlanner(t *testing.T) {
+	me := Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}
+	longRun := Route{[]Coord{{2, 1}, {3, 1}, {4, 1}, {5, 1}, {6, 1}}, 4.5}
+	tests := []struct {
+		name       string
+		me         Pac
+		enemies    []Pac
+		route      Route
+		enemyTypes map[PacType]int
+		expected   Action
+	}{
+		{
+			name:     "speed along a long pellet run",
+			me:       me,
+			route:    longRun,
+			expected: Speed{0, "FARM"},
+		},
+		{
+			name:  "don't speed for a few pellets",
+			me:    me,
+			route: Route{[]Coord{{2, 1}, {3, 1}}, 2},
+		},
+		{
+			name:  "don't speed while sped up",
+			me:    Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK", speedTurnsLeft: 3},
+			route: longRun,
+		},
+		{
+			name:  "wait for the cooldown",
+			me:    Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK", abilityCooldown: 1},
+			route: longRun,
+		},
+		{
+			name:    "keep the cooldown when an enemy is close",
+			me:      me,
+			enemies: []Pac{{id: 0, pos: Coord{7, 1}, typeID: "SCISSORS", abilityCooldown: 5}},
+			route:   longRun,
+		},
+		{
+			name:       "counter the most common enemy type",
+			me:         me,
+			route:      longRun,
+			enemyTypes: map[PacType]int{"PAPER": 3, "ROCK": 1},
+			expected:   Switch{0, "SCISSORS", "COUNTER"},
+		},
+		{
+			name:       "speed along a pellet run worth more than countering",
+			me:         me,
+			route:      Route{longRun.steps, 7},
+			enemyTypes: map[PacType]int{"PAPER": 3, "ROCK": 1},
+			expected:   Speed{0, "FARM"},
+		},
+		{
+			name:       "already countering the most common enemy type",
+			me:         Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "PAPER"},
+			route:      longRun,
+			enemyTypes: map[PacType]int{"ROCK": 2, "PAPER": 1},
+			expected:   Speed{0, "FARM"},
+		},
+		{
+			name:     "ignore dead enemies",
+			me:       me,
+			enemies:  []Pac{{id: 0, pos: Coord{2, 1}, typeID: deadTypeID}},
+			route:    longRun,
+			expected: Speed{0, "FARM"},
+		},
+		{
+			name:  "dead pacs don't use abilities",
+			me:    Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: deadTypeID},
+			route: longRun,
+		},
+		{
+			name:       "no enemy type common enough to counter",
+			me:         me,
+			enemyTypes: map[PacType]int{"ROCK": 1, "PAPER": 1, "SCISSORS": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gameMap := BuildGameMap(longCorridorMap)
+			planner := newAbilityPlanner(newDistanceTable(gameMap), defaultAbilityWeights)
+			gameData := GameData{1, gameMap, []int{0, 0}, append([]Pac{tt.me}, tt.enemies...), nil}
+
+			action, found := planner.plan(tt.me, gameData, tt.route, tt.enemyTypes)
+			if found != (tt.expected != nil) || !reflect.DeepEqual(tt.expected, action) {
+				t.Errorf("expected %v, but got %v", tt.expected, action)
+			}
+		})
+	}
+}
+
+func TestAbilityPlannerWeights(t *testing.T) {
+	gameMap := BuildGameMap(longCorridorMap)
+	me := Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}
+	enemy := Pac{id: 0, pos: Coord{7, 1}, typeID: "SCISSORS", abilityCooldown: 5}
+	gameData := GameData{1, gameMap, []int{0, 0}, []Pac{me, enemy}, nil}
+	route := Route{[]Coord{{2, 1}, {3, 1}}, 2}
+
+	// a reckless planner speeds for fewer pellets, with enemies closer by
+	planner := newAbilityPlanner(newDistanceTable(gameMap), AbilityWeights{farmValue: 1, reserveDistance: 3, counterShare: 0.5})
+	if action, found := planner.plan(me, gameData, route, nil); !found || action != (Speed{0, "FARM"}) {
+		t.Errorf("expected a reckless planner to speed up, but got %v", action)
+	}
+}
+
+func TestAbilityPlannerCountersOnePacPerTurn(t *testing.T) {
+	gameMap := BuildGameMap(longCorridorMap)
+	planner := newAbilityPlanner(newDistanceTable(gameMap), defaultAbilityWeights)
+	pacs := []Pac{{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}, {id: 1, mine: true, pos: Coord{3, 1}, typeID: "ROCK"}}
+	enemyTypes := map[PacType]int{"PAPER": 2}
+
+	gameData := GameData{1, gameMap, []int{0, 0}, pacs, nil}
+	if action, found := planner.plan(pacs[0], gameData, Route{}, enemyTypes); !found || action != (Switch{0, "SCISSORS", "COUNTER"}) {
+		t.Errorf("expected the first pac to counter, but got %v", action)
+	}
+	if action, found := planner.plan(pacs[1], gameData, Route{}, enemyTypes); found {
+		t.Errorf("expected the second pac to wait for another turn, but got %v", action)
+	}
+	gameData.round++
+	if action, found := planner.plan(pacs[1], gameData, Route{}, enemyTypes); !found || action != (Switch{1, "SCISSORS", "COUNTER"}) {
+		t.Errorf("expected the second pac to counter on the next turn, but got %v", action)
+	}
+}
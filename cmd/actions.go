@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------------
+// Actions: the commands a bot gives its pacs, and their wire format
+//-----------------------------------------------------------------------------------
+
+// maxMessageLength is the longest message a pac is allowed to display
+const maxMessageLength = 30
+
+// Action is a command given to a single pac
+type Action interface {
+	// actorID returns the id of the pac the action is for
+	actorID() int
+	// String returns the action in the wire format, e.g. "MOVE 0 3 4 hello"
+	String() string
+}
+
+// Move sends a pac toward target, along a shortest path picked by the game
+type Move struct {
+	pacID   int
+	target  Coord
+	message string
+}
+
+// Speed makes a pac move two cells per turn for the next few turns
+type Speed struct {
+	pacID   int
+	message string
+}
+
+// Switch changes a pac's type
+type Switch struct {
+	pacID   int
+	typeID  PacType
+	message string
+}
+
+func (move Move) actorID() int {
+	return move.pacID
+}
+
+func (move Move) String() string {
+	return withMessage(joinStrings("MOVE", move.pacID, move.target.x, move.target.y), move.message)
+}
+
+func (speed Speed) actorID() int {
+	return speed.pacID
+}
+
+func (speed Speed) String() string {
+	return withMessage(joinStrings("SPEED", speed.pacID), speed.message)
+}
+
+func (switchAction Switch) actorID() int {
+	return switchAction.pacID
+}
+
+func (switchAction Switch) String() string {
+	return withMessage(joinStrings("SWITCH", switchAction.pacID, switchAction.typeID), switchAction.message)
+}
+
+func withMessage(command, message string) string {
+	if message == "" {
+		return command
+	}
+	return command + " " + message
+}
+
+func actionMessage(action Action) string {
+	switch action := action.(type) {
+	case Move:
+		return action.message
+	case Speed:
+		return action.message
+	case Switch:
+		return action.message
+	}
+	return ""
+}
+
+// encodeActions returns the command line for actions, in the format the game expects on stdout
+func encodeActions(actions []Action) string {
+	commands := make([]string, len(actions))
+	for i, action := range actions {
+		commands[i] = action.String()
+	}
+	return strings.Join(commands, "|")
+}
+
+// parseActions parses a command line in the format the game expects on stdout. Every word following an action's arguments is its message
+func parseActions(command string) ([]Action, error) {
+	var actions []Action
+	for _, text := range strings.Split(command, "|") {
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("invalid command: %q", text)
+		}
+		pacID, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid pac id: %q", text)
+		}
+
+		switch fields[0] {
+		case "MOVE":
+			if len(fields) < 4 {
+				return nil, fmt.Errorf("invalid MOVE: %q", text)
+			}
+			x, xErr := strconv.Atoi(fields[2])
+			y, yErr := strconv.Atoi(fields[3])
+			if xErr != nil || yErr != nil {
+				return nil, fmt.Errorf("invalid MOVE target: %q", text)
+			}
+			actions = append(actions, Move{pacID, Coord{x, y}, strings.Join(fields[4:], " ")})
+		case "SPEED":
+			actions = append(actions, Speed{pacID, strings.Join(fields[2:], " ")})
+		case "SWITCH":
+			if len(fields) < 3 {
+				return nil, fmt.Errorf("invalid SWITCH: %q", text)
+			}
+			actions = append(actions, Switch{pacID, PacType(fields[2]), strings.Join(fields[3:], " ")})
+		default:
+			return nil, fmt.Errorf("unknown command: %q", text)
+		}
+	}
+	return actions, nil
+}
+
+// validateActions checks that actions can be carried out by my pacs in gameData: every action must be for a different pac of mine that is
+// alive, switch to a valid type, and carry a message that fits on a single command line
+func validateActions(actions []Action, gameData GameData) error {
+	myPacs := make(map[int]Pac)
+	for _, pac := range gameData.visiblePacs {
+		if pac.mine {
+			myPacs[pac.id] = pac
+		}
+	}
+
+	commanded := make(map[int]bool)
+	for _, action := range actions {
+		pac, found := myPacs[action.actorID()]
+		switch {
+		case !found:
+			return fmt.Errorf("%q: I have no pac %v", action, action.actorID())
+		case pac.typeID == deadTypeID:
+			return fmt.Errorf("%q: pac %v is dead", action, pac.id)
+		case commanded[pac.id]:
+			return fmt.Errorf("%q: pac %v already has a command", action, pac.id)
+		}
+		commanded[pac.id] = true
+
+		if switchAction, ok := action.(Switch); ok && !isPacType(switchAction.typeID) {
+			return fmt.Errorf("%q: unknown type %v", action, switchAction.typeID)
+		}
+		if message := actionMessage(action); len(message) > maxMessageLength || strings.ContainsAny(message, "|\n") {
+			return fmt.Errorf("%q: messages must be a single line of at most %v characters without a pipe", action, maxMessageLength)
+		}
+	}
+	return nil
+}
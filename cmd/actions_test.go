@@ -0,0 +1,82 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncodeActions(t *testing.T) {
+	actions := []Action{Move{0, Coord{3, 4}, "hello there"}, Speed{1, "ZOOM"}, Switch{2, "PAPER", ""}}
+	if expected, actual := "MOVE 0 3 4 hello there|SPEED 1 ZOOM|SWITCH 2 PAPER", encodeActions(actions); expected != actual {
+		t.Errorf("expected %q, but got %q", expected, actual)
+	}
+}
+
+func TestParseActions(t *testing.T) {
+	tests := []struct {
+		command  string
+		expected []Action
+	}{
+		{"", nil},
+		{"MOVE 0 3 4", []Action{Move{0, Coord{3, 4}, ""}}},
+		{"MOVE 0 3 4 hello there|SPEED 1 ZOOM|SWITCH 2 PAPER", []Action{Move{0, Coord{3, 4}, "hello there"}, Speed{1, "ZOOM"}, Switch{2, "PAPER", ""}}},
+		{"SPEED  0 ZOOM | SWITCH 1 ROCK oops", []Action{Speed{0, "ZOOM"}, Switch{1, "ROCK", "oops"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			actual, err := parseActions(tt.command)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tt.expected, actual) {
+				t.Errorf("expected %v, but got %v", tt.expected, actual)
+			}
+			if roundTrip, err := parseActions(encodeActions(actual)); err != nil || !reflect.DeepEqual(actual, roundTrip) {
+				t.Errorf("expected %v to survive a round trip, but got %v (%v)", actual, roundTrip, err)
+			}
+		})
+	}
+}
+
+func TestParseActionsErrors(t *testing.T) {
+	for _, command := range []string{"MOVE", "MOVE 0 1", "MOVE a 1 1", "MOVE 0 1 b", "JUMP 0", "SWITCH 0", "MOVE 0 1 1|HELLO"} {
+		t.Run(command, func(t *testing.T) {
+			if actions, err := parseActions(command); err == nil {
+				t.Errorf("expected an error, but got %v", actions)
+			}
+		})
+	}
+}
+
+func TestValidateActions(t *testing.T) {
+	gameData := GameData{visiblePacs: []Pac{
+		{id: 0, mine: true, typeID: "ROCK"},
+		{id: 1, mine: true, typeID: deadTypeID},
+		{id: 2, mine: false, typeID: "PAPER"},
+	}}
+	tests := []struct {
+		description string
+		actions     []Action
+		expected    string
+	}{
+		{"valid", []Action{Move{pacID: 0, message: "hi"}}, ""},
+		{"unknown pac", []Action{Move{pacID: 3}}, "no pac"},
+		{"enemy pac", []Action{Speed{pacID: 2}}, "no pac"},
+		{"dead pac", []Action{Speed{pacID: 1}}, "dead"},
+		{"several commands", []Action{Speed{pacID: 0}, Move{pacID: 0}}, "already"},
+		{"unknown type", []Action{Switch{pacID: 0, typeID: "LIZARD"}}, "unknown type"},
+		{"long message", []Action{Move{pacID: 0, message: strings.Repeat("a", maxMessageLength+1)}}, "messages"},
+		{"pipe in message", []Action{Speed{pacID: 0, message: "a|b"}}, "messages"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			err := validateActions(tt.actions, gameData)
+			if tt.expected == "" && err != nil {
+				t.Errorf("expected no error, but got %v", err)
+			} else if tt.expected != "" && (err == nil || !strings.Contains(err.Error(), tt.expected)) {
+				t.Errorf("expected an error about %q, but got %v", tt.expected, err)
+			}
+		})
+	}
+}
@@ -1,17 +1,35 @@
 package main
 
 import (
-	"bufio"
+	"bytes"
+	"flag"
 	"fmt"
+	"io"
+	"math"
 	"math/rand"
 	"os"
 	"sort"
 	"strings"
+	"time"
 )
 
 // Coord is a point in cartesian space
 type Coord struct{ x, y int }
 
+// PacType is what a pac is: one of the three types that beat each other in a circle, or dead
+type PacType string
+
+const (
+	rockTypeID     PacType = "ROCK"
+	paperTypeID    PacType = "PAPER"
+	scissorsTypeID PacType = "SCISSORS"
+	// deadTypeID is the type given to a pac that has been eaten. Dead pacs stay where they died, and can't move, eat or be eaten
+	deadTypeID PacType = "DEAD"
+)
+
+// pacTypes holds the types a pac that's alive can be, each beaten by the next one
+var pacTypes = []PacType{rockTypeID, paperTypeID, scissorsTypeID}
+
 // Pac represents a Pac man (or woman)
 type Pac struct {
 	// id is the pac's id (unique for a given player)
@@ -20,8 +38,8 @@ type Pac struct {
 	mine bool
 	// pos is the pac's positoin
 	pos Coord
-	// typeID is the pac's type (ROCK or PAPER or SCISSORS). In the next league, a pac that has died will be of type DEAD.
-	typeID string
+	// typeID is the pac's type (ROCK or PAPER or SCISSORS), or DEAD once it has been eaten
+	typeID PacType
 	// speedTurnsLeft is the number of remaining turns before the speed effect fades
 	speedTurnsLeft int
 	// abilityCooldown is the number of turns until you can request a new ability for this pac (SWITCH and SPEED)
@@ -40,10 +58,12 @@ type Cell struct {
 	value rune
 }
 
-// GameMap represents the walls and floors of the game area
+// GameMap represents the walls and floors of the game area. Use newGameMap to create one with its movement graph precomputed
 type GameMap struct {
 	width, height int
 	cells         []Cell
+	// adjacency holds the absolute positions of the cells reachable in one move from each cell, indexed by absolute position
+	adjacency [][]int
 }
 
 // GetCell gets the Cell value at the given Coord, or panics if not in range
@@ -110,6 +130,19 @@ func (gm GameMap) Wrap(coord Coord) Coord {
 	return Coord{_wrap(coord.x, gm.width), _wrap(coord.y, gm.height)}
 }
 
+// rows returns each row of the map as it appears in the game input: space " " is floor, pound "#" is wall
+func (gm GameMap) rows() []string {
+	rows := make([]string, gm.height)
+	for y := range rows {
+		row := make([]rune, gm.width)
+		for x := range row {
+			row[x] = gm.GetCell(Coord{x, y}).value
+		}
+		rows[y] = string(row)
+	}
+	return rows
+}
+
 // GameData represents a snapshot of the game at a point in time
 type GameData struct {
 	round          int
@@ -119,157 +152,48 @@ type GameData struct {
 	visiblePellets []Pellet
 }
 
-// Agent decides on a command given game data
+// Agent decides on the actions of its pacs given game data
 type Agent interface {
-	makeCommand(GameData) string
+	makeCommand(GameData) []Action
 }
 
 //-----------------------------------------------------------------------------------
 // general utility stuff
 //-----------------------------------------------------------------------------------
 
-func adjacentCoords(pos Coord) []Coord {
-	adjacents := []Coord{}
-
-	for dx := -1; dx <= 1; dx++ {
-		for dy := -1; dy <= 1; dy++ {
-			if dx != 0 || dy != 0 {
-				adjacents = append(adjacents, Coord{pos.x + dx, pos.y + dy})
-			}
-		}
-	}
-	return adjacents
-}
-
-// enemiesWithinRange returns all enemies within the given distance, sorted by distance
-// TODO: more tests
-func enemiesWithinRange(gameMap GameMap, pacsByPosition map[Coord]Pac, pos Coord, distance int) []Pac {
-	type SearchNode struct {
-		pos   Coord
-		depth int
-	}
-
-	queue := []SearchNode{{pos, 0}}
-	// list of nodes already visited to avoid cycles
-	visited := map[Coord]bool{pos: true}
-
-	enemies := []Pac{}
-
-	for len(queue) > 0 {
-		// queue.peek
-		node := queue[0]
-		// queue.dequeue
-		queue = queue[1:]
-
-		// if this position contains an enemy pac, add it to our list of enemies
-		pac, isPacPresent := pacsByPosition[node.pos]
-		if isPacPresent && !pac.mine {
-			enemies = append(enemies, pac)
-		}
-
-		// walk each orthogonal (x, y) coordinate
-		for _, adjacent := range adjacentCoords(node.pos) {
-			dPos := gameMap.Wrap(adjacent)
-			alreadyVisited := visited[dPos]
-			// if we haven't visited this coordinate before in this search
-			if !alreadyVisited {
-				visited[dPos] = true
-				cell := gameMap.GetCell(dPos)
-				// if the cell is a floor (instead of a wall)
-				if cell.value == ' ' {
-					// don't process nodes that are farther away than our max distance
-					if node.depth+1 <= distance {
-						queue = append(queue, SearchNode{dPos, node.depth + 1})
-					}
-				}
-			}
-		}
-	}
-
-	return enemies
-}
-
-func getWinningTypeId(typeId string) string {
-	if typeId == "ROCK" {
-		return "PAPER"
-	} else if typeId == "PAPER" {
-		return "SCISSORS"
-	} else if typeId == "SCISSORS" {
-		return "ROCK"
-	} else {
+func getWinningTypeId(typeId PacType) PacType {
+	switch typeId {
+	case rockTypeID:
+		return paperTypeID
+	case paperTypeID:
+		return scissorsTypeID
+	case scissorsTypeID:
+		return rockTypeID
+	case deadTypeID:
+		// nothing can eat a pac that's already dead
+		return deadTypeID
+	default:
 		panic(fmt.Sprintf("unknown typeId: %v", typeId))
 	}
 }
 
-// awayFrom returns a traversable coordinate one move away from me in the opposite direction of them
-func awayFrom(me Coord, them Coord, gameMap GameMap) Coord {
-	dx := []int{0}
-	dy := []int{0}
-
-	// TODO: doesn't account for wrapping around the map
-	if them.x >= me.x {
-		dx = append(dx, -1)
-	}
-	if them.x <= me.x {
-		dx = append(dx, +1)
-	}
-	if them.y >= me.y {
-		dy = append(dy, -1)
-	}
-	if them.y <= me.y {
-		dy = append(dy, +1)
-	}
-
-	for _, newX := range dx {
-		for _, newY := range dy {
-			if newX != 0 || newY != 0 {
-				newCoord := gameMap.Wrap(Coord{me.x + newX, me.y + newY})
-				if gameMap.GetCell(newCoord).value == ' ' {
-					return newCoord
-				}
-			}
+// sortCoords sorts (in place) area by pellet value, then by how far pos has to walk per pellet it can expect to find
+func sortCoords(area []Coord, pos Coord, pellets *PelletBeliefs, distances *DistanceTable) {
+	cost := func(coord Coord) float64 {
+		if probability := pellets.probability(coord); probability > 0 {
+			return float64(distances.distance(pos, coord)) / probability
 		}
+		return math.Inf(1)
 	}
-
-	// ¯\_(ツ)_/¯
-	return me
-}
-
-// sortCoords sorts (in place) area by value, then distance
-func sortCoords(area []Coord, pos Coord, pelletValuesByPos map[Coord]int) {
-	sort.Slice(area, func(i, j int) bool {
+	sort.SliceStable(area, func(i, j int) bool {
 		iCoord, jCoord := area[i], area[j]
-		iValue, iExists := pelletValuesByPos[iCoord]
-		jValue, jExists := pelletValuesByPos[jCoord]
-		if !iExists && jExists {
-			return true
-		} else if iExists && !jExists {
-			return false
-		} else if iValue < jValue {
-			return false
-		} else if iValue > jValue {
-			return true
-		} else {
-			iDistance, jDistance := iCoord.distanceSquared(pos), jCoord.distanceSquared(pos)
-			return iDistance < jDistance
+		if iValue, jValue := pellets.value(iCoord), pellets.value(jCoord); iValue != jValue {
+			return iValue > jValue
 		}
+		return cost(iCoord) < cost(jCoord)
 	})
 }
 
-// distanceSquared calculates the squared distance between this Coord and the given Coord
-func (coord Coord) distanceSquared(other Coord) int {
-	dx, dy := coord.x-other.x, coord.y-other.y
-	return dx*dx + dy*dy
-}
-
-// bucketize returns the bucket (0...numBuckets-1) that value x belongs in, if evenly distributed amongst width
-func bucketize(x, numBuckets, width int) int {
-	if bucket := x / (width / numBuckets); bucket < numBuckets {
-		return bucket
-	}
-	return numBuckets - 1
-}
-
 func joinStrings(elems ...interface{}) string {
 	elemStrings := make([]string, len(elems))
 	for i, elem := range elems {
@@ -285,103 +209,177 @@ func joinStrings(elems ...interface{}) string {
 
 // DansLilHeuristicBot is just a lil guy tryina eat some pellets
 type DansLilHeuristicBot struct {
-	// pelletValuesByPos keeps track of each pellet value based on its absolute position in the grid
-	pelletValuesByPos []int
-	// pelletValuesByCoord keeps track of each pellet value based on its coordinate position. I made this because I regretted storing the info in an array in pelletValuesByPos
-	pelletValuesByCoord map[Coord]int
-	pacsByPos           map[Coord]Pac
+	// pellets keeps track of where the pellets are likely to be
+	pellets *PelletBeliefs
+	// pacsByPos holds the pacs we can see that are still alive
+	pacsByPos map[Coord]Pac
+	// enemies follows enemy pacs once they go out of sight
+	enemies *EnemyTracker
+	// distances holds the walking distance between every pair of cells, computed once the map is known
+	distances *DistanceTable
+	// tasks decides which pellets each of my pacs goes after
+	tasks *TaskAllocator
+	// routes plans the way to those pellets
+	routes *RoutePlanner
+	// collisions keeps my pacs from blocking each other
+	collisions *CollisionResolver
+	// combat decides what to do when enemies come close
+	combat *CombatEngine
+	// traps finds the enemies my pacs can corner
+	traps *TrapFinder
+	// abilities decides when to speed up or switch outside of fights
+	abilities *AbilityPlanner
+	// casualties counts the pacs each side has lost
+	casualties *CasualtyLog
 }
 
 func (bot *DansLilHeuristicBot) init(gameMap GameMap) {
-	bot.pelletValuesByPos = make([]int, gameMap.width*gameMap.height)
-	bot.pelletValuesByCoord = make(map[Coord]int, gameMap.width*gameMap.height)
-	for pos, cell := range gameMap.cells {
-		coord := gameMap.GetCoord(pos)
-		var value int
-		if cell.value == ' ' {
-			value = 1
-		} else {
-			value = 0
-		}
-		bot.pelletValuesByPos[pos] = value
-		bot.pelletValuesByCoord[coord] = value
-	}
 	bot.pacsByPos = make(map[Coord]Pac)
+	bot.distances = newDistanceTable(gameMap)
+	bot.pellets = newPelletBeliefs(gameMap, bot.distances)
+	bot.enemies = newEnemyTracker(gameMap)
+	maze := newMazeGraph(gameMap)
+	bot.tasks = newTaskAllocator(maze, bot.distances)
+	bot.routes = newRoutePlanner(gameMap, bot.distances)
+	bot.collisions = newCollisionResolver(gameMap, bot.distances)
+	bot.combat = newCombatEngine(gameMap, bot.distances, maze)
+	bot.traps = newTrapFinder(gameMap, bot.distances, maze)
+	bot.abilities = newAbilityPlanner(bot.distances, defaultAbilityWeights)
+	bot.casualties = newCasualtyLog()
 }
 
 func (bot *DansLilHeuristicBot) update(gameData GameData) {
-	// clear all known pellet values for all currently visible cells -- we'll replace the existing values based on observed data next
-	for _, pac := range gameData.visiblePacs {
-		// only clear cells for my pacs
-		if pac.mine {
-			for _, coord := range gameData.gameMap.VisibleCells(pac.pos) {
-				bot.pelletValuesByPos[gameData.gameMap.GetAbsolutePosition(coord)] = 0
-			}
-		}
-	}
-	// update pellet values for all visible pellets
-	for _, pellet := range gameData.visiblePellets {
-		bot.pelletValuesByPos[gameData.gameMap.GetAbsolutePosition(pellet.pos)] = pellet.value
-		bot.pelletValuesByCoord[pellet.pos] = pellet.value
-	}
+	bot.pellets.observe(gameData)
+	bot.enemies.observe(gameData)
+	bot.casualties.observe(gameData)
 
-	// update pacs by position
+	// update pacs by position. Enemies lurking out of sight could be on any of many cells, so they're left to the combat engine rather
+	// than blocking all of them
 	bot.pacsByPos = make(map[Coord]Pac)
 	for _, pac := range gameData.visiblePacs {
-		bot.pacsByPos[pac.pos] = pac
+		if pac.typeID != deadTypeID {
+			bot.pacsByPos[pac.pos] = pac
+		}
 	}
 }
 
-func (bot DansLilHeuristicBot) makeCommand(gameData GameData) string {
+func (bot DansLilHeuristicBot) makeCommand(gameData GameData) []Action {
+	return bot.makeTimedCommand(gameData, nil)
+}
+
+// makeTimedCommand falls back to a cheap command for every pac it couldn't get to before deadline
+func (bot DansLilHeuristicBot) makeTimedCommand(gameData GameData, deadline *Deadline) []Action {
 	bot.update(gameData)
 
 	var myPacs []Pac
-	// find all of my pacs from the visible collection
+	// find all of my pacs still alive from the visible collection
 	for _, pac := range gameData.visiblePacs {
-		if pac.mine {
+		if pac.mine && pac.typeID != deadTypeID {
 			myPacs = append(myPacs, pac)
 		}
 	}
+	bot.collisions.observe(myPacs)
+
+	// route returns the route pac should follow to target (rather than whichever one the server picks), keeping clear of other pacs,
+	// of the cells in avoid, of the cell it is backing off from after getting stuck, and of any cell it gave up on getting through
+	route := func(pac Pac, target Coord, avoid map[Coord]bool) Route {
+		occupied := make(map[Coord]bool)
+		for pos := range bot.pacsByPos {
+			occupied[pos] = pos != pac.pos
+		}
+		for pos := range avoid {
+			occupied[pos] = true
+		}
+		if cell, stuck := bot.collisions.stuckOn(pac.id); stuck {
+			occupied[cell] = true
+		}
+		if cell, gaveUp := bot.collisions.gaveUpOn(pac.id); gaveUp {
+			occupied[cell] = true
+		}
+		return bot.routes.plan(pac, target, bot.pellets, occupied, deadline)
+	}
+	// walk returns where pac should move this turn to follow its route to target
+	walk := func(pac Pac, target Coord, avoid map[Coord]bool) Coord {
+		return bot.routes.nextTarget(pac, route(pac, target, avoid))
+	}
 
-	// partition pellets into mutually exclusive zones for each pac
-	pelletsByArea := make([][]Coord, len(myPacs))
-	for pos, pelletValue := range bot.pelletValuesByPos {
-		if pelletValue > 0 {
-			coord := gameData.gameMap.GetCoord(pos)
-			key := bucketize(coord.x, len(myPacs), gameData.gameMap.width)
-			pelletsByArea[key] = append(pelletsByArea[key], coord)
+	// enemies in sight, and those that may be lurking nearby
+	var enemies []Pac
+	for _, pac := range gameData.visiblePacs {
+		if !pac.mine && pac.typeID != deadTypeID {
+			enemies = append(enemies, pac)
+		}
+	}
+	lurking := bot.enemies.lurkingEnemies(recentSightingTurns)
+
+	// enemies in sight that are stuck in a corridor get closed in on from every way out
+	trapped := make(map[int]Action)
+	if !deadline.expired() {
+		for _, trap := range bot.traps.find(myPacs, enemies) {
+			for _, role := range trap.roles {
+				message := "BLOCK"
+				if role.chaser {
+					message = "TRAP"
+				}
+				trapped[role.pac.id] = Move{role.pac.id, bot.traps.target(trap, role), message}
+			}
 		}
 	}
 
-	var actions []string
-	for iPac, pac := range myPacs {
-		speed := func(status string) string { return joinStrings("SPEED ", pac.id, status) }
-		move := func(pos Coord, status string) string { return joinStrings("MOVE", pac.id, pos.x, pos.y, iPac, status) }
-		switchType := func(typeId string) string { return joinStrings("SWITCH", pac.id, typeId) }
-		var action string
-
-		// find any enemies within "striking distance"
-		enemies := enemiesWithinRange(gameData.gameMap, bot.pacsByPos, pac.pos, 4)
-		if len(enemies) > 0 {
-			nearest := enemies[0]
-			winningTypeId := getWinningTypeId(nearest.typeID)
-			if winningTypeId == pac.typeID {
-				if pac.abilityCooldown <= 0 {
-					action = speed("ZOOM")
-				} else {
-					action = move(nearest.pos, "NOM")
+	// send each pac after different pellets, preferably in its own territory. Pacs stuck on their way are free to pick other pellets
+	targets := make(map[int]Coord)
+	if !deadline.expired() {
+		territories := newTerritories(gameData.gameMap, bot.distances, append(append(append([]Pac(nil), myPacs...), enemies...), lurking...))
+		debugf("territory balance: %.1f\n", territories.balance(bot.pellets))
+		if !deadline.expired() {
+			for _, pac := range myPacs {
+				if _, stuck := bot.collisions.stuckOn(pac.id); stuck {
+					bot.tasks.reconsider(pac.id)
 				}
-			} else if pac.abilityCooldown <= 0 {
-				action = switchType(winningTypeId)
-			} else {
-				action = move(awayFrom(pac.pos, nearest.pos, gameData.gameMap), "EEK!")
+			}
+			targets = bot.tasks.assign(myPacs, bot.pellets, territories, bot.collisions.blocked(myPacs))
+		}
+	}
+	debugf("casualties: %v kills, %v losses\n", len(bot.casualties.kills), len(bot.casualties.losses))
+	enemyTypes := bot.enemies.typeCounts()
+
+	var actions []Action
+	// following holds the pellet target of every pac walking a route to it this turn, by pac id
+	following := make(map[int]Coord)
+	for iPac, pac := range myPacs {
+		move := func(pos Coord, status string) Action { return Move{pac.id, pos, joinStrings(iPac, status)} }
+		switchType := func(typeId PacType) Action { return Switch{pac.id, typeId, ""} }
+		var action Action
+
+		if deadline.expired() {
+			// out of time, just do something sensible
+			action = fallbackAction(pac, gameData)
+		} else if trapAction, found := trapped[pac.id]; found {
+			action = trapAction
+		} else if advice, found := bot.combat.advise(pac, enemies, lurking); found {
+			// there are enemies we could eat, or be eaten by, over the next few turns
+			switch advice.tactic {
+			case chaseTactic:
+				action = move(advice.target, "NOM")
+			case switchTactic:
+				action = switchType(advice.typeID)
+			case fleeTactic:
+				action = move(advice.target, "EEK!")
+			default:
+				action = move(pac.pos, "HOLD")
 			}
 		} else {
-			// choose closest pellet TODO: fix locking conditions
-			myArea := pelletsByArea[iPac]
-			if len(myArea) > 0 {
-				sortCoords(myArea, pac.pos, bot.pelletValuesByCoord)
-				action = move(pelletsByArea[iPac][0], joinStrings("P", len(pelletsByArea[iPac])))
+			target, found := targets[pac.id]
+			var pelletRoute Route
+			if found {
+				pelletRoute = route(pac, target, nil)
+			}
+			if ability, use := bot.abilities.plan(pac, gameData, pelletRoute, enemyTypes); use {
+				// speed up along a long pellet run, or switch ahead of time to counter the enemies
+				action = ability
+			} else if found {
+				action = move(bot.routes.nextTarget(pac, pelletRoute), joinStrings("P", target.x, target.y))
+				following[pac.id] = target
 			} else {
 				// wander aimlessly, hoping to find more delicious pellets
 				coord := func(x int) int {
@@ -392,96 +390,153 @@ func (bot DansLilHeuristicBot) makeCommand(gameData GameData) string {
 			}
 		}
 
-		if len(action) > 0 {
-			actions = append(actions, action)
-		}
+		actions = append(actions, action)
 	}
 
-	return strings.Join(actions, "|")
+	// pacs that would block each other make way for one another, but keep going where they were headed: a pac after pellets finds another
+	// route to them, and any other move (a chase, an escape, a trap) takes a detour towards its target
+	return bot.collisions.resolve(myPacs, actions, func(pac Pac, action Action, avoid map[Coord]bool) Action {
+		move, ok := action.(Move)
+		if !ok {
+			return action
+		}
+		if target, found := following[pac.id]; found {
+			return Move{pac.id, walk(pac, target, avoid), move.message}
+		}
+		return Move{pac.id, bot.collisions.detour(pac, move.target, avoid), move.message}
+	})
 }
 
 //-----------------------------------------------------------------------------------
 // main stuff
 //-----------------------------------------------------------------------------------
 
+// debugOutput is where debug and debugf write: stderr, unless something (like a replay recorder) is capturing it
+var debugOutput io.Writer = os.Stderr
+
 func debug(a ...interface{}) {
-	fmt.Fprintln(os.Stderr, a...)
+	fmt.Fprintln(debugOutput, a...)
 }
 
 func debugf(format string, a ...interface{}) {
-	fmt.Fprintf(os.Stderr, format, a...)
+	fmt.Fprintf(debugOutput, format, a...)
 }
 
 /**
  * Grab the pellets as fast as you can!
  **/
 func main() {
-	agent := DansLilHeuristicBot{}
-
-	scanner := bufio.NewScanner(os.Stdin)
-	scanner.Buffer(make([]byte, 1000000), 1000000)
-
-	// width: size of the grid
-	// height: top left corner is (x=0, y=0)
-	var width, height int
-	scanner.Scan()
-	fmt.Sscan(scanner.Text(), &width, &height)
-	var cells []Cell
-
-	for i := 0; i < height; i++ {
-		scanner.Scan()
-		row := scanner.Text() // one line of the grid: space " " is floor, pound "#" is wall
-		for _, cellValue := range row {
-			cells = append(cells, Cell{cellValue})
+	replayPath := flag.String("replay", "", "record the game to a replay file at this path")
+	logPath := flag.String("log", "", "record every input line and printed command to a game log at this path")
+	viewPath := flag.String("view", "", "browse the game log or replay file at this path instead of playing")
+	viewPlayer := flag.Int("player", 0, "player whose point of view is shown when browsing a replay file")
+	agentName := flag.String("agent", "heuristic", "agent that plays the game: heuristic or mcts")
+	flag.Parse()
+
+	if *viewPath != "" {
+		if err := view(*viewPath, *viewPlayer); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
+		return
 	}
 
-	gameMap := GameMap{width, height, cells}
-	agent.init(gameMap)
-	var gameRound = 0
-
-	for {
-		var myScore, opponentScore int
-		scanner.Scan()
-		fmt.Sscan(scanner.Text(), &myScore, &opponentScore)
-		// visiblePacCount: all your pacs and enemy pacs in sight
-		var visiblePacCount int
-		scanner.Scan()
-		fmt.Sscan(scanner.Text(), &visiblePacCount)
-
-		var visiblePacs []Pac
-
-		for i := 0; i < visiblePacCount; i++ {
-			var pacID int
-			var player int
-			var x, y int
-			var typeID string
-			var speedTurnsLeft, abilityCooldown int
-			scanner.Scan()
-			fmt.Sscan(scanner.Text(), &pacID, &player, &x, &y, &typeID, &speedTurnsLeft, &abilityCooldown)
-
-			visiblePacs = append(visiblePacs, Pac{pacID, player == 1, Coord{x, y}, typeID, speedTurnsLeft, abilityCooldown})
+	var agent Agent
+	switch *agentName {
+	case "heuristic":
+		agent = &DansLilHeuristicBot{}
+	case "mcts":
+		agent = newMCTSAgent(time.Now().UnixNano(), defaultMCTSSearchTime)
+	default:
+		fmt.Fprintln(os.Stderr, "unknown agent:", *agentName)
+		os.Exit(2)
+	}
+	gameLog := createOutputFile(*logPath, "game log")
+	replay := createOutputFile(*replayPath, "replay")
+	if err := playGame(agent, os.Stdin, os.Stdout, gameLog, replay); err != nil {
+		debug(err)
+		os.Exit(1)
+	}
+}
+
+// playGame makes agent play the game whose input is read from in, printing its commands to out, until the input ends.
+// Every input line and command is copied to gameLog, and every turn is recorded to replay, unless they're nil.
+func playGame(agent Agent, in io.Reader, out io.Writer, gameLog, replay io.Writer) error {
+	reader := newProtocolReader(in)
+	logLines := func(lines ...string) {
+		if gameLog != nil {
+			for _, line := range lines {
+				fmt.Fprintln(gameLog, line)
+			}
 		}
-		// visiblePelletCount: all pellets in sight
-		var visiblePelletCount int
-		scanner.Scan()
-		fmt.Sscan(scanner.Text(), &visiblePelletCount)
+	}
 
-		var visiblePellets []Pellet
+	gameMap, err := reader.readInit()
+	logLines(reader.rawLines()...)
+	if err != nil {
+		return err
+	}
+	// the arena's first turn limit includes the time it takes to initialize
+	initStart := reader.blockStart
+	if initAgent, ok := agent.(initializer); ok {
+		initAgent.init(gameMap)
+	}
+
+	var recorder *ReplayRecorder
+	var turnDebug bytes.Buffer
+	if replay != nil {
+		if recorder, err = newReplayRecorder(replay, gameMap); err != nil {
+			debug("couldn't record replay:", err)
+		}
+		previousOutput := debugOutput
+		debugOutput = io.MultiWriter(previousOutput, &turnDebug)
+		defer func() { debugOutput = previousOutput }()
+	}
 
-		for i := 0; i < visiblePelletCount; i++ {
-			// value: amount of points this pellet is worth
-			var x, y, value int
-			scanner.Scan()
-			fmt.Sscan(scanner.Text(), &x, &y, &value)
+	for firstTurn := true; ; firstTurn = false {
+		gameData, err := reader.readTurn()
+		logLines(reader.rawLines()...)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
 
-			visiblePellets = append(visiblePellets, Pellet{Coord{x, y}, value})
+		start := reader.blockStart
+		if firstTurn {
+			start = initStart
 		}
-		gameData := GameData{gameRound, gameMap, []int{myScore, opponentScore}, visiblePacs, visiblePellets}
-		cmd := agent.makeCommand(gameData)
+		deadline := arenaTimeBudget.deadline(firstTurn, start)
+		actions := makeCommandBefore(agent, gameData, deadline)
+		if err := validateActions(actions, gameData); err != nil {
+			debug("invalid actions:", err)
+		}
+		cmd := encodeActions(actions)
 		debug(cmd)
-		fmt.Println(cmd)
+		fmt.Fprintln(out, cmd)
+		logLines(cmd)
+		debugf("turn %v: answered in %v of %v\n", gameData.round, deadline.elapsed().Round(time.Microsecond), deadline.limit)
+
+		if recorder != nil {
+			if err := recorder.recordTurn(ReplayTurn{gameData.round, nil, []ReplayPlayerTurn{{gameData, cmd, turnDebug.String()}}}); err != nil {
+				debug("couldn't record replay:", err)
+				recorder = nil
+			}
+			turnDebug.Reset()
+		}
+	}
+}
 
-		gameRound++
+// createOutputFile creates the file at path, or returns nil if there's no path or (after complaining) if the file can't be created:
+// losing a log is no reason to stop playing
+func createOutputFile(path, description string) io.Writer {
+	if path == "" {
+		return nil
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		debug("couldn't record "+description+":", err)
+		return nil
 	}
+	return file
 }
@@ -0,0 +1,70 @@
+package main
+
+//-----------------------------------------------------------------------------------
+// Casualties: the pacs each side has lost over the game
+//-----------------------------------------------------------------------------------
+
+// pacKey tells pacs apart across both players, since each player numbers their pacs from 0
+type pacKey struct {
+	id   int
+	mine bool
+}
+
+// Casualty is a pac that was eaten, as it was last seen alive, and the round it was found dead on
+type Casualty struct {
+	pac   Pac
+	round int
+}
+
+// CasualtyLog keeps track of the enemy pacs we've eaten and of the pacs we've lost, turn after turn. Enemies eaten out of our sight
+// can't be told apart from those hiding in the fog, so they aren't counted
+type CasualtyLog struct {
+	// alive holds every pac last seen alive, as it was then
+	alive map[pacKey]Pac
+	// dead is true for every pac known to be dead
+	dead map[pacKey]bool
+	// kills and losses hold the enemy pacs we've eaten and my pacs that have been eaten, in the order they were found dead
+	kills, losses []Casualty
+}
+
+func newCasualtyLog() *CasualtyLog {
+	return &CasualtyLog{alive: make(map[pacKey]Pac), dead: make(map[pacKey]bool)}
+}
+
+// observe updates the log with the input of a turn. Pacs listed as DEAD have been eaten, and so have my pacs that stop being listed at
+// all (before the Silver league, dead pacs aren't listed)
+func (casualties *CasualtyLog) observe(gameData GameData) {
+	listed := make(map[pacKey]bool)
+	for _, pac := range gameData.visiblePacs {
+		key := pacKey{pac.id, pac.mine}
+		listed[key] = true
+		if pac.typeID != deadTypeID {
+			casualties.alive[key] = pac
+			continue
+		}
+		last, seen := casualties.alive[key]
+		if !seen {
+			last = pac
+		}
+		casualties.died(key, last, gameData.round)
+	}
+	for key, pac := range casualties.alive {
+		if key.mine && !listed[key] {
+			casualties.died(key, pac, gameData.round)
+		}
+	}
+}
+
+// died records the death of pac, unless it's already known
+func (casualties *CasualtyLog) died(key pacKey, pac Pac, round int) {
+	delete(casualties.alive, key)
+	if casualties.dead[key] {
+		return
+	}
+	casualties.dead[key] = true
+	if key.mine {
+		casualties.losses = append(casualties.losses, Casualty{pac, round})
+	} else {
+		casualties.kills = append(casualties.kills, Casualty{pac, round})
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCasualtyLog(t *testing.T) {
+	gameMap := BuildGameMap(longCorridorMap)
+	rock := Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}
+	paper := Pac{id: 1, mine: true, pos: Coord{7, 1}, typeID: "PAPER"}
+	enemy := Pac{id: 0, pos: Coord{3, 1}, typeID: "SCISSORS"}
+	dead := func(pac Pac) Pac {
+		pac.typeID = deadTypeID
+		return pac
+	}
+
+	casualties := newCasualtyLog()
+	casualties.observe(GameData{0, gameMap, []int{0, 0}, []Pac{rock, paper, enemy}, nil})
+	// the enemy gets eaten in sight, and my paper pac out of it
+	casualties.observe(GameData{1, gameMap, []int{0, 0}, []Pac{rock, paper, dead(enemy)}, nil})
+	casualties.observe(GameData{2, gameMap, []int{0, 0}, []Pac{rock, dead(paper), dead(enemy)}, nil})
+	// before the Silver league, dead pacs just stop being listed
+	casualties.observe(GameData{3, gameMap, []int{0, 0}, nil, nil})
+
+	if expected := []Casualty{{enemy, 1}}; !reflect.DeepEqual(expected, casualties.kills) {
+		t.Errorf("expected kills %v, but got %v", expected, casualties.kills)
+	}
+	if expected := []Casualty{{paper, 2}, {rock, 3}}; !reflect.DeepEqual(expected, casualties.losses) {
+		t.Errorf("expected losses %v, but got %v", expected, casualties.losses)
+	}
+	if !casualties.dead[pacKey{0, true}] || !casualties.dead[pacKey{0, false}] || casualties.dead[pacKey{1, false}] {
+		t.Errorf("expected my pacs and the enemy we ate to be dead, but got %v", casualties.dead)
+	}
+}
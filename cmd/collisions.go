@@ -0,0 +1,202 @@
+package main
+
+import "math/rand"
+
+//-----------------------------------------------------------------------------------
+// Collisions: my pacs block each other when they move into the same cell or swap cells, so make sure they don't try
+//-----------------------------------------------------------------------------------
+
+const (
+	// stallsBeforeGivingUp is how many times a pac may fail to move into the same cell before it gives up on getting through it
+	stallsBeforeGivingUp = 3
+	// stallMemoryTurns is how many turns a stall is remembered for after the last time the pac failed to move into its cell
+	stallMemoryTurns = 10
+)
+
+// moveAttempt is a pac's attempt at leaving from for to on the previous turn
+type moveAttempt struct {
+	from, to Coord
+}
+
+// CollisionResolver changes my pacs' commands so that they don't block each other, and notices the pacs that got stuck anyway (on an
+// enemy of the same type, or a friend whose command we mispredicted)
+type CollisionResolver struct {
+	gameMap   GameMap
+	distances *DistanceTable
+	// attempts holds the first step of every pac told to move last turn, by pac id
+	attempts map[int]moveAttempt
+	// stalls holds the cell each pac last failed to move into, by pac id. It outlives the turns the pac waits or backs off, so that a pac
+	// blocked over and over (by an enemy of the same type heading the same way) eventually gives up instead of retrying forever
+	stalls map[int]stall
+	// turn counts the calls to observe
+	turn int
+}
+
+// stall is a pac's repeated failure to move into the same cell
+type stall struct {
+	cell Coord
+	// count is how many times the pac failed to move into cell
+	count int
+	// turn is the last turn the pac failed to move into cell
+	turn int
+	// until is the last turn the pac keeps away from cell before trying again
+	until int
+}
+
+func newCollisionResolver(gameMap GameMap, distances *DistanceTable) *CollisionResolver {
+	return &CollisionResolver{gameMap: gameMap, distances: distances, attempts: make(map[int]moveAttempt), stalls: make(map[int]stall)}
+}
+
+// observe finds the pacs that were told to move last turn but are still where they were, and forgets the stalls of pacs that have since
+// made it into the cell they were blocked on, or haven't been blocked on it for stallMemoryTurns
+func (resolver *CollisionResolver) observe(myPacs []Pac) {
+	resolver.turn++
+	alive := make(map[int]bool)
+	for _, pac := range myPacs {
+		alive[pac.id] = true
+		previous, found := resolver.stalls[pac.id]
+		if attempt, told := resolver.attempts[pac.id]; told && attempt.from == pac.pos {
+			if !found || previous.cell != attempt.to {
+				previous = stall{cell: attempt.to}
+			}
+			// back off for a random number of turns, doubling with every stall, so that two pacs blocking each other the same way (like
+			// mirror images of each other, played by the same bot) don't keep trying again on the same turn
+			count := previous.count + 1
+			resolver.stalls[pac.id] = stall{attempt.to, count, resolver.turn, resolver.turn + rand.Intn(1<<uint(count))}
+		} else if found && (previous.cell == pac.pos || resolver.turn-previous.turn > stallMemoryTurns) {
+			delete(resolver.stalls, pac.id)
+		}
+	}
+	for id := range resolver.stalls {
+		if !alive[id] {
+			delete(resolver.stalls, id)
+		}
+	}
+	resolver.attempts = make(map[int]moveAttempt)
+}
+
+// stuckOn returns the cell the pac with id is backing off from, having failed to move into it recently, if any
+func (resolver *CollisionResolver) stuckOn(id int) (Coord, bool) {
+	stall, found := resolver.stalls[id]
+	return stall.cell, found && resolver.turn <= stall.until
+}
+
+// gaveUpOn returns the cell the pac with id has failed to move into stallsBeforeGivingUp times, if any: the pac should find a way around
+// it, or other pellets to eat
+func (resolver *CollisionResolver) gaveUpOn(id int) (Coord, bool) {
+	stall, found := resolver.stalls[id]
+	return stall.cell, found && stall.count >= stallsBeforeGivingUp
+}
+
+// blocked returns the cell each of myPacs gave up on, by pac id
+func (resolver *CollisionResolver) blocked(myPacs []Pac) map[int]Coord {
+	cells := make(map[int]Coord)
+	for _, pac := range myPacs {
+		if cell, gaveUp := resolver.gaveUpOn(pac.id); gaveUp {
+			cells[pac.id] = cell
+		}
+	}
+	return cells
+}
+
+// steps returns the cells pac is expected to walk through this turn when given action: the first one or two steps of a shortest path to
+// a move's target, depending on its speed, and none for any other action
+func (resolver *CollisionResolver) steps(pac Pac, action Action) []Coord {
+	move, ok := action.(Move)
+	if !ok {
+		return nil
+	}
+	var steps []Coord
+	for from := pac.pos; len(steps) < 1 || (len(steps) < 2 && pac.speedTurnsLeft > 0); {
+		next := resolver.distances.firstStep(from, move.target)
+		if next == from {
+			break
+		}
+		steps = append(steps, next)
+		from = next
+	}
+	return steps
+}
+
+// detour returns where pac should move this turn to get closer to target without entering the cells in avoid: the neighbor nearest to
+// target, or its own cell if no neighbor gets it any closer. A pac whose own cell is to be avoided steps aside to whichever neighbor is
+// nearest to target, since staying put would block the pac walking into it
+func (resolver *CollisionResolver) detour(pac Pac, target Coord, avoid map[Coord]bool) Coord {
+	best, bestDistance := pac.pos, resolver.distances.distance(pac.pos, target)
+	if avoid[pac.pos] {
+		bestDistance = unreachable + 1
+	}
+	for _, neighbor := range resolver.gameMap.Neighbors(pac.pos) {
+		if distance := resolver.distances.distance(neighbor, target); !avoid[neighbor] && distance < bestDistance {
+			best, bestDistance = neighbor, distance
+		}
+	}
+	return best
+}
+
+// collision returns the indices of the first two pacs that would block each other if they walked steps, following the referee's rules
+func collision(pacs []Pac, steps [][]Coord) (int, int, bool) {
+	positions := make([]Coord, len(pacs))
+	for i, pac := range pacs {
+		positions[i] = pac.pos
+	}
+	for step := 0; step < 2; step++ {
+		next := make([]Coord, len(pacs))
+		for i := range pacs {
+			next[i] = positions[i]
+			if step < len(steps[i]) {
+				next[i] = steps[i][step]
+			}
+		}
+		for i := range pacs {
+			for j := i + 1; j < len(pacs); j++ {
+				if next[i] == next[j] || (next[i] == positions[j] && next[j] == positions[i]) {
+					return i, j, true
+				}
+			}
+		}
+		positions = next
+	}
+	return 0, 0, false
+}
+
+// resolve returns actions (one for each of myPacs, in the same order) changed so that no two pacs block each other. When two pacs would,
+// the one with the higher id (or the one moving, if the other stays put) makes way: replan is asked for another action, given the pac's
+// original one, that keeps out of the cells in avoid, and failing that, it stays put
+func (resolver *CollisionResolver) resolve(myPacs []Pac, actions []Action, replan func(pac Pac, action Action, avoid map[Coord]bool) Action) []Action {
+	actions = append([]Action(nil), actions...)
+	replanned := make(map[int]bool)
+	for attempt := 0; attempt < 2*len(myPacs); attempt++ {
+		steps := make([][]Coord, len(myPacs))
+		for i, pac := range myPacs {
+			steps[i] = resolver.steps(pac, actions[i])
+		}
+		i, j, found := collision(myPacs, steps)
+		if !found {
+			break
+		}
+
+		loser, winner := j, i
+		if len(steps[j]) == 0 || (len(steps[i]) > 0 && myPacs[i].id > myPacs[j].id) {
+			loser, winner = i, j
+		}
+		pac := myPacs[loser]
+		if replanned[loser] {
+			actions[loser] = Move{pac.id, pac.pos, actionMessage(actions[loser])}
+			continue
+		}
+		replanned[loser] = true
+		avoid := map[Coord]bool{myPacs[winner].pos: true}
+		for _, step := range steps[winner] {
+			avoid[step] = true
+		}
+		actions[loser] = replan(pac, actions[loser], avoid)
+	}
+
+	for i, pac := range myPacs {
+		if steps := resolver.steps(pac, actions[i]); len(steps) > 0 {
+			resolver.attempts[pac.id] = moveAttempt{pac.pos, steps[0]}
+		}
+	}
+	return actions
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCollision(t *testing.T) {
+	a := Pac{id: 0, mine: true, pos: Coord{1, 1}}
+	b := Pac{id: 1, mine: true, pos: Coord{3, 1}}
+
+	tests := []struct {
+		name     string
+		steps    [][]Coord
+		expected bool
+	}{
+		{"moving into the same cell", [][]Coord{{{2, 1}}, {{2, 1}}}, true},
+		{"swapping cells", [][]Coord{{{2, 1}, {3, 1}}, {{2, 1}, {1, 1}}}, true},
+		{"moving into a pac that stays put", [][]Coord{{{2, 1}, {3, 1}}, nil}, true},
+		{"following a pac", [][]Coord{{{2, 1}, {3, 1}}, {{4, 1}, {5, 1}}}, false},
+		{"moving apart", [][]Coord{{{0, 1}}, {{4, 1}}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, actual := collision([]Pac{a, b}, tt.steps); tt.expected != actual {
+				t.Errorf("expected collision %v, but got %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCollisionResolverMakesWay(t *testing.T) {
+	gameMap := BuildGameMap(corridorMap)
+	resolver := newCollisionResolver(gameMap, newDistanceTable(gameMap))
+	pacs := []Pac{{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}, {id: 1, mine: true, pos: Coord{3, 1}, typeID: "ROCK"}}
+	actions := []Action{Move{0, Coord{3, 1}, ""}, Move{1, Coord{1, 1}, ""}}
+
+	var replanned []Pac
+	var avoided map[Coord]bool
+	var original Action
+	resolved := resolver.resolve(pacs, actions, func(pac Pac, action Action, avoid map[Coord]bool) Action {
+		replanned, original, avoided = append(replanned, pac), action, avoid
+		return Move{pac.id, Coord{5, 1}, "make way"}
+	})
+
+	if expected := []Pac{pacs[1]}; !reflect.DeepEqual(expected, replanned) {
+		t.Errorf("expected only %v to make way, but got %v", expected, replanned)
+	}
+	if expected := actions[1]; expected != original {
+		t.Errorf("expected to replan %v, but got %v", expected, original)
+	}
+	if expected := map[Coord]bool{{1, 1}: true, {2, 1}: true}; !reflect.DeepEqual(expected, avoided) {
+		t.Errorf("expected the replanned route to avoid %v, but got %v", expected, avoided)
+	}
+	if expected := []Action{actions[0], Move{1, Coord{5, 1}, "make way"}}; !reflect.DeepEqual(expected, resolved) {
+		t.Errorf("expected actions %v, but got %v", expected, resolved)
+	}
+}
+
+func TestCollisionResolverStaysPutWhenReplanningFails(t *testing.T) {
+	gameMap := BuildGameMap(corridorMap)
+	resolver := newCollisionResolver(gameMap, newDistanceTable(gameMap))
+	pacs := []Pac{{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}, {id: 1, mine: true, pos: Coord{3, 1}, typeID: "ROCK"}}
+	actions := []Action{Move{0, Coord{2, 1}, ""}, Move{1, Coord{2, 1}, "P"}}
+
+	resolved := resolver.resolve(pacs, actions, func(pac Pac, action Action, avoid map[Coord]bool) Action { return action })
+
+	if expected := []Action{actions[0], Move{1, Coord{3, 1}, "P"}}; !reflect.DeepEqual(expected, resolved) {
+		t.Errorf("expected actions %v, but got %v", expected, resolved)
+	}
+}
+
+func TestCollisionResolverDetectsStuckPacs(t *testing.T) {
+	gameMap := BuildGameMap(corridorMap)
+	resolver := newCollisionResolver(gameMap, newDistanceTable(gameMap))
+	pacs := []Pac{{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}, {id: 1, mine: true, pos: Coord{5, 1}, typeID: "ROCK"}}
+	resolver.resolve(pacs, []Action{Move{0, Coord{3, 1}, ""}, Move{1, Coord{4, 1}, ""}}, nil)
+
+	// pac 1 moved, but pac 0 was blocked by something we didn't foresee
+	pacs[1].pos = Coord{4, 1}
+	resolver.observe(pacs)
+
+	if cell, stuck := resolver.stuckOn(0); !stuck || cell != (Coord{2, 1}) {
+		t.Errorf("expected pac 0 to be stuck on (2,1), but got %v, %v", cell, stuck)
+	}
+	if _, stuck := resolver.stuckOn(1); stuck {
+		t.Errorf("expected pac 1 not to be stuck")
+	}
+}
+
+func TestCollisionResolverGivesUpOnRepeatedStalls(t *testing.T) {
+	gameMap := BuildGameMap(corridorMap)
+	resolver := newCollisionResolver(gameMap, newDistanceTable(gameMap))
+	pacs := []Pac{{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}}
+	stallOnce := func() {
+		resolver.resolve(pacs, []Action{Move{0, Coord{3, 1}, ""}}, nil)
+		resolver.observe(pacs)
+	}
+
+	for stall := 1; stall < stallsBeforeGivingUp; stall++ {
+		stallOnce()
+		// waiting a turn doesn't make the pac forget it's been blocked
+		resolver.resolve(pacs, []Action{Move{0, Coord{1, 1}, ""}}, nil)
+		resolver.observe(pacs)
+	}
+	if cell, gaveUp := resolver.gaveUpOn(0); gaveUp {
+		t.Fatalf("expected pac 0 not to give up on %v yet", cell)
+	}
+	stallOnce()
+	if cell, gaveUp := resolver.gaveUpOn(0); !gaveUp || cell != (Coord{2, 1}) {
+		t.Errorf("expected pac 0 to give up on (2,1), but got %v, %v", cell, gaveUp)
+	}
+	if expected, actual := map[int]Coord{0: {2, 1}}, resolver.blocked(pacs); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected blocked cells %v, but got %v", expected, actual)
+	}
+
+	// once the pac makes it through, it's no longer blocked
+	pacs[0].pos = Coord{2, 1}
+	resolver.observe(pacs)
+	if _, gaveUp := resolver.gaveUpOn(0); gaveUp {
+		t.Errorf("expected pac 0 to be through with (2,1)")
+	}
+}
+
+func TestCollisionResolverDetour(t *testing.T) {
+	gameMap := BuildGameMap(ringMap)
+	resolver := newCollisionResolver(gameMap, newDistanceTable(gameMap))
+	pac := Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}
+
+	tests := []struct {
+		name     string
+		target   Coord
+		avoid    map[Coord]bool
+		expected Coord
+	}{
+		{"straight there", Coord{3, 1}, nil, Coord{2, 1}},
+		{"the other way around the ring", Coord{7, 3}, map[Coord]bool{{2, 1}: true}, Coord{1, 2}},
+		{"no way to get closer", Coord{3, 1}, map[Coord]bool{{2, 1}: true}, Coord{1, 1}},
+		{"out of the way of a pac walking in", Coord{3, 1}, map[Coord]bool{{2, 1}: true, {1, 1}: true}, Coord{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := resolver.detour(pac, tt.target, tt.avoid); tt.expected != actual {
+				t.Errorf("expected %v, but got %v", tt.expected, actual)
+			}
+		})
+	}
+}
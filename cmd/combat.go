@@ -0,0 +1,209 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+//-----------------------------------------------------------------------------------
+// Combat: whether a pac should chase, switch, hold or flee when enemies are around
+//-----------------------------------------------------------------------------------
+
+const (
+	// combatHorizon is how many turns ahead fights are foreseen
+	combatHorizon = 3
+	// killValue is what eating an enemy pac is worth
+	killValue = 10.0
+	// deathValue is what losing a pac costs, more than a kill is worth since it leaves us a pac short for the rest of the game
+	deathValue = 20.0
+	// switchEscapeChance is how likely a pac able to switch before contact is to switch out of a losing fight, leaving its
+	// attacker empty-handed
+	switchEscapeChance = 0.7
+	// runEscapeChance is how likely a pac at least as fast as its attacker is to outrun it, unless it's cornered in a dead end
+	runEscapeChance = 0.5
+	// switchAttackChance is how likely an enemy able to switch is to switch to the type that beats us, and come after us
+	switchAttackChance = 0.5
+)
+
+// Tactic is what a pac does in a fight
+type Tactic int
+
+const (
+	holdTactic Tactic = iota
+	chaseTactic
+	switchTactic
+	fleeTactic
+)
+
+func (tactic Tactic) String() string {
+	return [...]string{"hold", "chase", "switch", "flee"}[tactic]
+}
+
+// CombatAdvice is a tactic for one of my pacs, and what we expect it to be worth
+type CombatAdvice struct {
+	tactic Tactic
+	// target is the cell to chase, or the MOVE target that follows the escape route when fleeing
+	target Coord
+	// typeID is the type to switch to
+	typeID PacType
+	// killChance and deathChance are the chances of eating an enemy, and of being eaten, within combatHorizon turns
+	killChance, deathChance float64
+	// score is the expected value of the tactic: killValue for a kill, minus deathValue for a death
+	score float64
+}
+
+// CombatEngine weighs the fights my pacs could get into
+type CombatEngine struct {
+	gameMap   GameMap
+	distances *DistanceTable
+	escapes   *EscapePlanner
+}
+
+func newCombatEngine(gameMap GameMap, distances *DistanceTable, maze *MazeGraph) *CombatEngine {
+	return &CombatEngine{gameMap: gameMap, distances: distances, escapes: newEscapePlanner(gameMap, distances, maze)}
+}
+
+// turnsToReach returns how many turns pac takes to walk distance cells, at its current speed
+func turnsToReach(pac Pac, distance int) int {
+	turns := 0
+	for steps := 0; steps < distance; {
+		turns++
+		if turns <= pac.speedTurnsLeft {
+			steps += 2
+		} else {
+			steps++
+		}
+	}
+	return turns
+}
+
+// stepsPerTurn returns how many cells pac walks this turn
+func stepsPerTurn(pac Pac) int {
+	if pac.speedTurnsLeft > 0 {
+		return 2
+	}
+	return 1
+}
+
+// duel returns the chance that attacker eats defender within combatHorizon turns by chasing it down as they are now, after waiting delay
+// turns
+func (engine *CombatEngine) duel(attacker, defender Pac, delay int) float64 {
+	if attacker.typeID == deadTypeID || getWinningTypeId(defender.typeID) != attacker.typeID {
+		return 0
+	}
+	distance := engine.distances.distance(attacker.pos, defender.pos)
+	reach := delay + turnsToReach(attacker, distance)
+	if distance == unreachable || reach > combatHorizon {
+		return 0
+	}
+	chance := 1.0
+	if defender.abilityCooldown < reach {
+		chance *= 1 - switchEscapeChance
+	}
+	if stepsPerTurn(defender) >= stepsPerTurn(attacker) && engine.gameMap.Degree(defender.pos) > 1 {
+		chance *= 1 - runEscapeChance
+	}
+	return chance
+}
+
+// threat returns the chance that enemy eats me within combatHorizon turns, whether it already beats my type or switches to. An enemy
+// that has to switch first gives itself away a turn before it can eat me, which is all a pac whose ability is ready needs to switch back
+func (engine *CombatEngine) threat(enemy, me Pac) float64 {
+	chance := engine.duel(enemy, me, 0)
+	if winning := getWinningTypeId(me.typeID); enemy.typeID != winning && enemy.abilityCooldown == 0 && me.abilityCooldown > 0 {
+		switched := enemy
+		switched.typeID, switched.abilityCooldown = winning, abilityCooldownDuration
+		chance = math.Max(chance, switchAttackChance*engine.duel(switched, me, 1))
+	}
+	return chance
+}
+
+// outcome returns the chances that me eats at least one of enemies after waiting delay turns, and that at least one of them eats me. An
+// enemy lurking out of sight comes as one copy on every cell it may be on, but it's still only one pac: only its worst case counts
+func (engine *CombatEngine) outcome(me Pac, enemies []Pac, delay int) (float64, float64) {
+	indexes := make(map[pacKey]int)
+	var kills, threats []float64
+	for _, enemy := range enemies {
+		index, found := indexes[pacKey{enemy.id, enemy.mine}]
+		if !found {
+			index = len(kills)
+			indexes[pacKey{enemy.id, enemy.mine}] = index
+			kills, threats = append(kills, 0), append(threats, 0)
+		}
+		kills[index] = math.Max(kills[index], engine.duel(me, enemy, delay))
+		threats[index] = math.Max(threats[index], engine.threat(enemy, me))
+	}
+
+	survives, spared := 1.0, 1.0
+	for i := range kills {
+		survives *= 1 - kills[i]
+		spared *= 1 - threats[i]
+	}
+	return 1 - survives, 1 - spared
+}
+
+// evaluate returns every tactic pac can use against enemies in sight and the copies of those lurking out of sight, best first. Lurking
+// enemies are threats to flee or switch against, but they're never chased, since they're most likely not where each copy is. Enemies too
+// far away to matter within combatHorizon turns are ignored
+func (engine *CombatEngine) evaluate(pac Pac, enemies, lurking []Pac) []CombatAdvice {
+	var nearby, chased []Pac
+	for i, enemy := range append(append([]Pac(nil), enemies...), lurking...) {
+		if !enemy.mine && enemy.typeID != deadTypeID && engine.distances.distance(pac.pos, enemy.pos) <= 2*combatHorizon {
+			nearby = append(nearby, enemy)
+			if i < len(enemies) {
+				chased = append(chased, enemy)
+			}
+		}
+	}
+
+	advise := func(tactic Tactic, target Coord, typeID PacType, kill float64, me Pac) CombatAdvice {
+		_, death := engine.outcome(me, nearby, 0)
+		return CombatAdvice{tactic, target, typeID, kill, death, killValue*kill - deathValue*death}
+	}
+	// holding comes first, so that it wins ties with tactics that gain nothing
+	advice := []CombatAdvice{advise(holdTactic, pac.pos, "", 0, pac)}
+	for _, enemy := range chased {
+		if kill := engine.duel(pac, enemy, 0); kill > 0 {
+			advice = append(advice, advise(chaseTactic, enemy.pos, "", kill, pac))
+		}
+	}
+	if pac.abilityCooldown == 0 {
+		for _, typeID := range pacTypes {
+			if typeID != pac.typeID {
+				// switching takes a turn, so any chase starts a turn later
+				switched := pac
+				switched.typeID, switched.abilityCooldown = typeID, abilityCooldownDuration
+				kill, _ := engine.outcome(switched, nearby, 1)
+				advice = append(advice, advise(switchTactic, pac.pos, typeID, kill, switched))
+			}
+		}
+	}
+	var threats []Pac
+	for _, enemy := range nearby {
+		if engine.threat(enemy, pac) > 0 {
+			threats = append(threats, enemy)
+		}
+	}
+	if len(threats) > 0 {
+		// a pac fleeing is judged from where it'll be after this turn
+		escape := engine.escapes.plan(pac, threats)
+		fled := pac
+		for step := 0; step < len(escape.path) && step < stepsPerTurn(pac); step++ {
+			fled.pos = escape.path[step]
+		}
+		advice = append(advice, advise(fleeTactic, moveTarget(engine.gameMap, engine.distances, pac, escape.path), "", 0, fled))
+	}
+
+	sort.SliceStable(advice, func(i, j int) bool { return advice[i].score > advice[j].score })
+	return advice
+}
+
+// advise returns the best tactic for pac against enemies in sight and lurking ones, or false if the best it can do is to hold with nothing at stake, in which case it
+// may as well go about its business
+func (engine *CombatEngine) advise(pac Pac, enemies, lurking []Pac) (CombatAdvice, bool) {
+	best := engine.evaluate(pac, enemies, lurking)[0]
+	if best.tactic == holdTactic && best.deathChance == 0 {
+		return CombatAdvice{}, false
+	}
+	return best, true
+}
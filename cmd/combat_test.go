@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// longCorridorMap is a single horizontal corridor from (1,1) to (7,1)
+const longCorridorMap = `
+#########
+#       #
+#########`
+
+func TestTurnsToReach(t *testing.T) {
+	tests := []struct{ speed, distance, expected int }{
+		{0, 0, 0},
+		{0, 3, 3},
+		{1, 3, 2},
+		{5, 3, 2},
+		{5, 4, 2},
+		{2, 7, 5},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("speed %v, distance %v", tt.speed, tt.distance), func(t *testing.T) {
+			if actual := turnsToReach(Pac{speedTurnsLeft: tt.speed}, tt.distance); tt.expected != actual {
+				t.Errorf("expected %v, but got %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCombatEngineDuel(t *testing.T) {
+	gameMap := BuildGameMap(corridorMap)
+	engine := newCombatEngine(gameMap, newDistanceTable(gameMap), newMazeGraph(gameMap))
+	rock := Pac{id: 0, mine: true, pos: Coord{3, 1}, typeID: "ROCK", abilityCooldown: 5}
+
+	tests := []struct {
+		name     string
+		enemy    Pac
+		expected float64
+	}{
+		{"prey that can run", Pac{pos: Coord{4, 1}, typeID: "SCISSORS", abilityCooldown: 5, speedTurnsLeft: 1}, 0.5},
+		{"prey cornered in a dead end", Pac{pos: Coord{5, 1}, typeID: "SCISSORS", abilityCooldown: 5}, 1},
+		{"prey that can switch first", Pac{pos: Coord{5, 1}, typeID: "SCISSORS", abilityCooldown: 1}, 1 - switchEscapeChance},
+		{"a type we don't beat", Pac{pos: Coord{5, 1}, typeID: "PAPER", abilityCooldown: 5}, 0},
+		{"a dead pac", Pac{pos: Coord{4, 1}, typeID: deadTypeID}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := engine.duel(rock, tt.enemy, 0); tt.expected != actual {
+				t.Errorf("expected %v, but got %v", tt.expected, actual)
+			}
+			if actual := engine.duel(Pac{pos: rock.pos, typeID: deadTypeID}, tt.enemy, 0); actual != 0 {
+				t.Errorf("expected a dead pac not to eat anything, but got %v", actual)
+			}
+		})
+	}
+}
+
+func TestCombatEngineAdvise(t *testing.T) {
+	tests := []struct {
+		name     string
+		cells    string
+		me       Pac
+		enemy    Pac
+		expected CombatAdvice
+	}{
+		{
+			name:     "chase cornered prey",
+			cells:    corridorMap,
+			me:       Pac{id: 0, mine: true, pos: Coord{3, 1}, typeID: "ROCK", abilityCooldown: 5},
+			enemy:    Pac{id: 0, pos: Coord{1, 1}, typeID: "SCISSORS", abilityCooldown: 5},
+			expected: CombatAdvice{tactic: chaseTactic, target: Coord{1, 1}},
+		},
+		{
+			name:     "switch to the type that beats a cornered enemy",
+			cells:    corridorMap,
+			me:       Pac{id: 0, mine: true, pos: Coord{3, 1}, typeID: "ROCK"},
+			enemy:    Pac{id: 0, pos: Coord{5, 1}, typeID: "PAPER", abilityCooldown: 5},
+			expected: CombatAdvice{tactic: switchTactic, target: Coord{3, 1}, typeID: "SCISSORS"},
+		},
+		{
+			name:     "flee out of reach",
+			cells:    longCorridorMap,
+			me:       Pac{id: 0, mine: true, pos: Coord{4, 1}, typeID: "ROCK", abilityCooldown: 5},
+			enemy:    Pac{id: 0, pos: Coord{7, 1}, typeID: "PAPER", abilityCooldown: 5},
+			expected: CombatAdvice{tactic: fleeTactic, target: Coord{3, 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gameMap := BuildGameMap(tt.cells)
+			engine := newCombatEngine(gameMap, newDistanceTable(gameMap), newMazeGraph(gameMap))
+
+			advice, found := engine.advise(tt.me, []Pac{tt.enemy}, nil)
+			if !found {
+				t.Fatalf("expected advice")
+			}
+			if advice.tactic != tt.expected.tactic || advice.target != tt.expected.target || advice.typeID != tt.expected.typeID {
+				t.Errorf("expected to %v %v %v, but got %+v", tt.expected.tactic, tt.expected.target, tt.expected.typeID, advice)
+			}
+		})
+	}
+}
+
+func TestCombatEngineIgnoresFarAwayEnemies(t *testing.T) {
+	gameMap := BuildGameMap(ringMap)
+	engine := newCombatEngine(gameMap, newDistanceTable(gameMap), newMazeGraph(gameMap))
+	me := Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}
+	enemy := Pac{id: 0, pos: Coord{7, 3}, typeID: "PAPER"}
+
+	if advice, found := engine.advise(me, []Pac{enemy}, nil); found {
+		t.Errorf("expected no advice, but got %+v", advice)
+	}
+}
+
+func TestCombatEngineLetsPacsThatCanSwitchBackIgnoreSwitchThreats(t *testing.T) {
+	gameMap := BuildGameMap(corridorMap)
+	engine := newCombatEngine(gameMap, newDistanceTable(gameMap), newMazeGraph(gameMap))
+	me := Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}
+	enemy := Pac{id: 0, pos: Coord{3, 1}, typeID: "ROCK"}
+
+	if advice, found := engine.advise(me, []Pac{enemy}, nil); found {
+		t.Errorf("expected no advice, but got %+v", advice)
+	}
+	me.abilityCooldown = 5
+	if advice, found := engine.advise(me, []Pac{enemy}, nil); !found || advice.deathChance == 0 {
+		t.Errorf("expected a pac that can't switch back to be wary of the enemy switching, but got %+v, %v", advice, found)
+	}
+}
+
+func TestCombatEngineCountsLurkingEnemiesOnce(t *testing.T) {
+	gameMap := BuildGameMap(longCorridorMap)
+	engine := newCombatEngine(gameMap, newDistanceTable(gameMap), newMazeGraph(gameMap))
+	me := Pac{id: 0, mine: true, pos: Coord{3, 1}, typeID: "ROCK", abilityCooldown: 5}
+	// the same enemy, out of sight, on every cell it may be on
+	copies := []Pac{
+		{id: 0, pos: Coord{5, 1}, typeID: "PAPER", abilityCooldown: 5},
+		{id: 0, pos: Coord{6, 1}, typeID: "PAPER", abilityCooldown: 5},
+		{id: 0, pos: Coord{7, 1}, typeID: "PAPER", abilityCooldown: 5},
+	}
+	worst := engine.threat(copies[0], me)
+
+	if _, death := engine.outcome(me, copies, 0); death != worst {
+		t.Errorf("expected the chance of dying to be that of the closest copy %v, but got %v", worst, death)
+	}
+	other := Pac{id: 1, pos: Coord{1, 1}, typeID: "PAPER", abilityCooldown: 5}
+	expected := 1 - (1-worst)*(1-engine.threat(other, me))
+	if _, death := engine.outcome(me, append(copies, other), 0); math.Abs(expected-death) > 1e-9 {
+		t.Errorf("expected another enemy to add to the chance of dying %v, but got %v", expected, death)
+	}
+}
+
+func TestCombatEngineDoesntChaseLurkingEnemies(t *testing.T) {
+	gameMap := BuildGameMap(longCorridorMap)
+	engine := newCombatEngine(gameMap, newDistanceTable(gameMap), newMazeGraph(gameMap))
+	me := Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK", abilityCooldown: 5}
+	prey := Pac{id: 0, pos: Coord{3, 1}, typeID: "SCISSORS", abilityCooldown: 5}
+
+	if advice, found := engine.advise(me, []Pac{prey}, nil); !found || advice.tactic != chaseTactic {
+		t.Fatalf("expected to chase the enemy in sight, but got %+v, %v", advice, found)
+	}
+	for _, advice := range engine.evaluate(me, nil, []Pac{prey}) {
+		if advice.tactic == chaseTactic {
+			t.Errorf("expected not to chase an enemy out of sight, but got %+v", advice)
+		}
+	}
+}
@@ -8,22 +8,19 @@ import (
 func TestInitSeedsPelletsOnAllFloorCells(t *testing.T) {
 	bot := DansLilHeuristicBot{}
 
-	bot.init(BuildGameMap(`
+	gameMap := BuildGameMap(`
 ## ##
 #   #
-#####`))
-
-	expectedPelletValues := []int{0, 0, 1, 0, 0, 0, 1, 1, 1, 0, 0, 0, 0, 0, 0}
-	if expected, actual := len(expectedPelletValues), len(bot.pelletValuesByPos); expected != actual {
-		t.Errorf("expected length %v, but got %v", expected, actual)
-	} else {
-		for pos, tt := range expectedPelletValues {
-			t.Run(fmt.Sprint(pos), func(t *testing.T) {
-				if expected, actual := tt, bot.pelletValuesByPos[pos]; expected != actual {
-					t.Errorf("expected position %v to have pellet value %v, but got %v", pos, expected, actual)
-				}
-			})
-		}
+#####`)
+	bot.init(gameMap)
+
+	expectedProbabilities := []float64{0, 0, 1, 0, 0, 0, 1, 1, 1, 0, 0, 0, 0, 0, 0}
+	for pos, tt := range expectedProbabilities {
+		t.Run(fmt.Sprint(pos), func(t *testing.T) {
+			if expected, actual := tt, bot.pellets.probability(gameMap.GetCoord(pos)); expected != actual {
+				t.Errorf("expected position %v to have pellet probability %v, but got %v", pos, expected, actual)
+			}
+		})
 	}
 }
 
@@ -39,7 +36,7 @@ func TestUpdate(t *testing.T) {
 
 	preCheckPositions := []Coord{{1, 2}, {1, 3}}
 	for _, preCheckPosition := range preCheckPositions {
-		if actual := bot.pelletValuesByPos[gameMap.GetAbsolutePosition(preCheckPosition)]; actual <= 0 {
+		if actual := bot.pellets.probability(preCheckPosition); actual <= 0 {
 			t.Errorf("prerequisite failed: expected pellet value >0 at position %v, but got value %v", preCheckPosition, actual)
 			t.FailNow()
 		}
@@ -47,21 +44,130 @@ func TestUpdate(t *testing.T) {
 
 	pacCoord := Coord{1, 1}
 	visiblePellets := []Pellet{{Coord{1, 3}, 1}}
-	bot.update(GameData{gameMap: gameMap, visiblePacs: []Pac{{mine: true, pos: pacCoord}}, visiblePellets: visiblePellets})
+	bot.update(GameData{gameMap: gameMap, scores: []int{0, 0}, visiblePacs: []Pac{{mine: true, pos: pacCoord}}, visiblePellets: visiblePellets})
 
 	tests := []struct {
 		pos           Coord
-		expectedValue int
+		expectedValue float64
 	}{
 		{Coord{1, 2}, 0},
 		{Coord{1, 3}, 1},
 	}
 	for _, tt := range tests {
 		t.Run(fmt.Sprint(tt), func(t *testing.T) {
-			ttPos := gameMap.GetAbsolutePosition(tt.pos)
-			if expected, actual := tt.expectedValue, bot.pelletValuesByPos[ttPos]; expected != actual {
+			if expected, actual := tt.expectedValue, bot.pellets.expectedValue(tt.pos); expected != actual {
 				t.Errorf("expected %v, but got %v", expected, actual)
 			}
 		})
 	}
 }
+
+func TestMakeCommandReturnsValidActions(t *testing.T) {
+	gameMap := BuildGameMap(`
+#######
+#     #
+# ### #
+#     #
+#######`)
+	gameData := GameData{
+		gameMap: gameMap,
+		scores:  []int{0, 0},
+		visiblePacs: []Pac{
+			{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"},
+			{id: 1, mine: true, pos: Coord{5, 3}, typeID: "PAPER"},
+			{id: 0, mine: false, pos: Coord{3, 1}, typeID: "SCISSORS"},
+		},
+	}
+	bot := DansLilHeuristicBot{}
+	bot.init(gameMap)
+
+	actions := bot.makeCommand(gameData)
+
+	if err := validateActions(actions, gameData); err != nil {
+		t.Errorf("expected valid actions, but got %v: %v", encodeActions(actions), err)
+	}
+	if expected, actual := 2, len(actions); expected != actual {
+		t.Errorf("expected one action per pac, but got %v", encodeActions(actions))
+	}
+}
+
+func TestGetWinningTypeId(t *testing.T) {
+	tests := []struct {
+		typeID   PacType
+		expected PacType
+	}{
+		{rockTypeID, paperTypeID},
+		{paperTypeID, scissorsTypeID},
+		{scissorsTypeID, rockTypeID},
+		{deadTypeID, deadTypeID},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.typeID), func(t *testing.T) {
+			if actual := getWinningTypeId(tt.typeID); tt.expected != actual {
+				t.Errorf("expected %v, but got %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestMakeCommandIgnoresDeadPacs(t *testing.T) {
+	gameMap := BuildGameMap(`
+#######
+#     #
+# ### #
+#     #
+#######`)
+	alive := Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}
+	gameData := GameData{
+		gameMap: gameMap,
+		scores:  []int{0, 0},
+		visiblePacs: []Pac{
+			alive,
+			{id: 1, mine: true, pos: Coord{5, 3}, typeID: deadTypeID},
+			{id: 0, mine: false, pos: Coord{2, 1}, typeID: deadTypeID},
+		},
+	}
+	bot := DansLilHeuristicBot{}
+	bot.init(gameMap)
+
+	for round := 0; round < 2; round++ {
+		gameData.round = round
+		actions := bot.makeCommand(gameData)
+		if err := validateActions(actions, gameData); err != nil {
+			t.Errorf("expected valid actions, but got %v: %v", encodeActions(actions), err)
+		}
+		if len(actions) != 1 || actions[0].actorID() != alive.id {
+			t.Errorf("expected an action for the pac still alive only, but got %v", encodeActions(actions))
+		}
+	}
+	if _, occupied := bot.pacsByPos[Coord{2, 1}]; occupied {
+		t.Errorf("expected a dead pac not to block the way")
+	}
+	if !bot.casualties.dead[pacKey{0, false}] || !bot.casualties.dead[pacKey{1, true}] {
+		t.Errorf("expected both dead pacs to be accounted for, but got kills %v and losses %v", bot.casualties.kills, bot.casualties.losses)
+	}
+}
+
+func TestMakeCommandGoesAroundAPersistentBlocker(t *testing.T) {
+	gameMap := BuildGameMap(ringMap)
+	bot := DansLilHeuristicBot{}
+	bot.init(gameMap)
+	// no ability, so that every command is a move
+	pac := Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK", abilityCooldown: 10}
+
+	// an enemy of the same type, out of sight, walks into (2,1) every time the pac does, so they both bounce back
+	for round := 0; round < 40 && pac.pos != (Coord{7, 1}); round++ {
+		actions := bot.makeCommand(GameData{round, gameMap, []int{0, 0}, []Pac{pac}, rowPellets(1, 2, 7)})
+		move, ok := actions[0].(Move)
+		if !ok {
+			t.Fatalf("expected a move, but got %v", encodeActions(actions))
+		}
+		if step := bot.distances.firstStep(pac.pos, move.target); step != (Coord{2, 1}) {
+			pac.pos = step
+		}
+	}
+
+	if pac.pos != (Coord{7, 1}) {
+		t.Errorf("expected the pac to go the other way around the ring, through (7,1), but it's still at %v", pac.pos)
+	}
+}
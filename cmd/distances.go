@@ -0,0 +1,100 @@
+package main
+
+//-----------------------------------------------------------------------------------
+// Distances: true walking distances between every pair of floor cells
+//-----------------------------------------------------------------------------------
+
+// unreachable is the distance between two cells that can't be walked between, e.g. because one of them is a wall. It's large enough that
+// unreachable targets sort after every reachable one
+const unreachable = 1 << 30
+
+// DistanceTable answers the walking distance between any two cells of a map, and the first step to take from one toward the other, in
+// constant time. Cells are indexed by their absolute position in the map
+type DistanceTable struct {
+	gameMap GameMap
+	// distances holds the distance from every cell to every other cell: distances[from*size+to]
+	distances []int
+	// firstSteps holds the absolute position of the first cell on a shortest path from every cell to every other cell, laid out like
+	// distances. It's -1 when the target is unreachable or is the starting cell itself
+	firstSteps []int
+}
+
+// newDistanceTable runs a breadth first search from every floor cell of gameMap, wrapping around the edges of the map. For the largest
+// maps this takes a few milliseconds, well within the first turn's time limit
+func newDistanceTable(gameMap GameMap) *DistanceTable {
+	size := len(gameMap.cells)
+	table := &DistanceTable{gameMap, make([]int, size*size), make([]int, size*size)}
+	for i := range table.distances {
+		table.distances[i] = unreachable
+		table.firstSteps[i] = -1
+	}
+
+	queue := make([]int, 0, size)
+	for _, from := range gameMap.FloorPositions() {
+		distances, firstSteps := table.distances[from*size:(from+1)*size], table.firstSteps[from*size:(from+1)*size]
+		distances[from] = 0
+		queue = append(queue[:0], from)
+		for len(queue) > 0 {
+			node := queue[0]
+			queue = queue[1:]
+			for _, next := range gameMap.NeighborPositions(node) {
+				if distances[next] != unreachable {
+					continue
+				}
+				distances[next] = distances[node] + 1
+				// the first step toward next is the first step toward the cell we reached it from, unless that's where we started
+				if node == from {
+					firstSteps[next] = next
+				} else {
+					firstSteps[next] = firstSteps[node]
+				}
+				queue = append(queue, next)
+			}
+		}
+	}
+	return table
+}
+
+// distance returns the number of moves it takes to walk from one cell to another, or unreachable
+func (table *DistanceTable) distance(from, to Coord) int {
+	return table.distances[table.index(from, to)]
+}
+
+// firstStep returns the cell to move to from from in order to reach to along a shortest path. It returns from if to is from itself or
+// can't be reached
+func (table *DistanceTable) firstStep(from, to Coord) Coord {
+	if step := table.firstSteps[table.index(from, to)]; step >= 0 {
+		return table.gameMap.GetCoord(step)
+	}
+	return from
+}
+
+func (table *DistanceTable) index(from, to Coord) int {
+	gm := table.gameMap
+	return gm.GetAbsolutePosition(gm.Wrap(from))*len(gm.cells) + gm.GetAbsolutePosition(gm.Wrap(to))
+}
+
+// distancesAvoiding returns the walking distance from from to every cell, indexed by absolute position, for a pac that never enters the
+// cells in avoid (from itself excepted). Cells only reachable through avoid are unreachable
+func (table *DistanceTable) distancesAvoiding(from Coord, avoid map[Coord]bool) []int {
+	gm := table.gameMap
+	distances := make([]int, len(gm.cells))
+	for i := range distances {
+		distances[i] = unreachable
+	}
+	start := gm.GetAbsolutePosition(gm.Wrap(from))
+	distances[start] = 0
+	queue := []int{start}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, next := range gm.NeighborPositions(node) {
+			if distances[next] != unreachable || avoid[gm.GetCoord(next)] {
+				continue
+			}
+			distances[next] = distances[node] + 1
+			queue = append(queue, next)
+		}
+	}
+	return distances
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDistanceTable(t *testing.T) {
+	gameMap := BuildGameMap(`
+#######
+   #   
+## # ##
+##   ##
+#######`)
+	distances := newDistanceTable(gameMap)
+
+	tests := []struct {
+		from, to          Coord
+		expectedDistance  int
+		expectedFirstStep Coord
+	}{
+		{Coord{2, 1}, Coord{2, 1}, 0, Coord{2, 1}},
+		{Coord{2, 1}, Coord{1, 1}, 1, Coord{1, 1}},
+		// the tunnel wraps around the map, which is shorter than walking around the wall
+		{Coord{2, 1}, Coord{4, 1}, 5, Coord{1, 1}},
+		{Coord{0, 1}, Coord{6, 1}, 1, Coord{6, 1}},
+		{Coord{2, 1}, Coord{3, 3}, 3, Coord{2, 2}},
+		{Coord{-1, 1}, Coord{0, 1}, 1, Coord{0, 1}},
+		{Coord{2, 1}, Coord{3, 1}, unreachable, Coord{2, 1}},
+		{Coord{3, 1}, Coord{2, 1}, unreachable, Coord{3, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v to %v", tt.from, tt.to), func(t *testing.T) {
+			if expected, actual := tt.expectedDistance, distances.distance(tt.from, tt.to); expected != actual {
+				t.Errorf("expected distance %v, but got %v", expected, actual)
+			}
+			if expected, actual := tt.expectedFirstStep, distances.firstStep(tt.from, tt.to); expected != actual {
+				t.Errorf("expected first step %v, but got %v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestDistanceTableIsSymmetric(t *testing.T) {
+	gameMap := generateMap(3, MapGeneratorOptions{}).gameMap
+	distances := newDistanceTable(gameMap)
+	for from := range gameMap.cells {
+		for to := range gameMap.cells {
+			fromCoord, toCoord := gameMap.GetCoord(from), gameMap.GetCoord(to)
+			if there, back := distances.distance(fromCoord, toCoord), distances.distance(toCoord, fromCoord); there != back {
+				t.Fatalf("expected the distance from %v to %v to be the same both ways, but got %v and %v", fromCoord, toCoord, there, back)
+			}
+		}
+	}
+}
+
+func TestDistancesAvoiding(t *testing.T) {
+	gameMap := BuildGameMap(ringMap)
+	distances := newDistanceTable(gameMap).distancesAvoiding(Coord{1, 1}, map[Coord]bool{{2, 1}: true, {7, 2}: true})
+
+	tests := []struct {
+		to       Coord
+		expected int
+	}{
+		{Coord{1, 1}, 0},
+		{Coord{1, 3}, 2},
+		// the way round the ring to the right is cut off at both ends
+		{Coord{3, 1}, unreachable},
+		{Coord{2, 1}, unreachable},
+		{Coord{7, 3}, 8},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.to), func(t *testing.T) {
+			if actual := distances[gameMap.GetAbsolutePosition(tt.to)]; tt.expected != actual {
+				t.Errorf("expected distance %v, but got %v", tt.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import "sort"
+
+//-----------------------------------------------------------------------------------
+// Enemy tracker: where enemy pacs can be while they're out of sight
+//-----------------------------------------------------------------------------------
+
+// recentSightingTurns is how many turns an enemy pac that went out of sight is still considered a threat around where it was seen.
+// Past that, it could be almost anywhere and isn't worth running from
+const recentSightingTurns = 3
+
+// TrackedEnemy is what we know about an enemy pac: how it was the last time we saw it, and every cell it could have reached since
+type TrackedEnemy struct {
+	sighting enemySighting
+	// possible is true for the absolute position of every cell the pac may be standing on now
+	possible []bool
+	// visible is true if the pac is in sight this turn
+	visible bool
+	// inferred is true if we haven't actually seen the pac yet, and only know where it started from the map's symmetry
+	inferred bool
+}
+
+// EnemyTracker follows every enemy pac we've seen, through the fog of war
+type EnemyTracker struct {
+	gameMap GameMap
+	enemies map[int]*TrackedEnemy
+	// round is the round of the last observed turn
+	round int
+}
+
+func newEnemyTracker(gameMap GameMap) *EnemyTracker {
+	return &EnemyTracker{gameMap: gameMap, enemies: make(map[int]*TrackedEnemy)}
+}
+
+// observe updates the tracker with the input of a turn: enemies in sight are where we see them, the others spread out from where they
+// could have been last turn, except onto cells our pacs can see
+func (tracker *EnemyTracker) observe(gameData GameData) {
+	gm := tracker.gameMap
+	for _, enemy := range tracker.enemies {
+		enemy.visible = false
+		for round := tracker.round + 1; round <= gameData.round; round++ {
+			turns := round - enemy.sighting.round
+			for step := enemy.sighting.maxSteps(turns - 1); step < enemy.sighting.maxSteps(turns); step++ {
+				tracker.spread(enemy)
+			}
+		}
+	}
+	tracker.round = gameData.round
+
+	if gameData.round == 0 {
+		// nobody has moved yet, so the enemies we can't see are where the map's symmetry puts them
+		for _, twin := range mirroredEnemies(gameData) {
+			enemy := &TrackedEnemy{sighting: enemySighting{twin, 0}, possible: make([]bool, len(gm.cells)), inferred: true}
+			enemy.possible[gm.GetAbsolutePosition(twin.pos)] = true
+			tracker.enemies[twin.id] = enemy
+		}
+	}
+
+	for _, pac := range gameData.visiblePacs {
+		if pac.mine {
+			continue
+		}
+		enemy, tracked := tracker.enemies[pac.id]
+		if !tracked {
+			enemy = &TrackedEnemy{possible: make([]bool, len(gm.cells))}
+			tracker.enemies[pac.id] = enemy
+		}
+		enemy.sighting = enemySighting{pac, gameData.round}
+		enemy.visible, enemy.inferred = pac.typeID != deadTypeID, false
+		for pos := range enemy.possible {
+			enemy.possible[pos] = false
+		}
+		// a dead pac isn't anywhere it could harm us anymore
+		enemy.possible[gm.GetAbsolutePosition(pac.pos)] = enemy.visible
+	}
+
+	for _, pac := range gameData.visiblePacs {
+		if !pac.mine || pac.typeID == deadTypeID {
+			continue
+		}
+		for _, coord := range gm.VisibleCells(pac.pos) {
+			pos := gm.GetAbsolutePosition(coord)
+			for _, enemy := range tracker.enemies {
+				if !enemy.visible {
+					enemy.possible[pos] = false
+				}
+			}
+		}
+	}
+}
+
+// spread adds every cell one move away from a cell the enemy may be on to the cells it may be on
+func (tracker *EnemyTracker) spread(enemy *TrackedEnemy) {
+	var reached []int
+	for pos, possible := range enemy.possible {
+		if possible {
+			reached = append(reached, tracker.gameMap.NeighborPositions(pos)...)
+		}
+	}
+	for _, pos := range reached {
+		enemy.possible[pos] = true
+	}
+}
+
+// possiblePositions returns every cell the enemy pac with id may be standing on, in row order. It's empty for a pac we've never seen,
+// or that has vanished from everywhere it could have been (most likely because it was eaten)
+func (tracker *EnemyTracker) possiblePositions(id int) []Coord {
+	var coords []Coord
+	if enemy, tracked := tracker.enemies[id]; tracked {
+		for pos, possible := range enemy.possible {
+			if possible {
+				coords = append(coords, tracker.gameMap.GetCoord(pos))
+			}
+		}
+	}
+	return coords
+}
+
+// lurkingEnemies returns a copy of each enemy pac out of sight, seen no more than maxTurnsUnseen turns ago, on every cell it may be
+// standing on, with its cooldown and speed boost worn down by the turns since. They're sorted by id, then position
+func (tracker *EnemyTracker) lurkingEnemies(maxTurnsUnseen int) []Pac {
+	ids := make([]int, 0, len(tracker.enemies))
+	for id := range tracker.enemies {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	var pacs []Pac
+	for _, id := range ids {
+		enemy := tracker.enemies[id]
+		if enemy.visible || tracker.round-enemy.sighting.round > maxTurnsUnseen {
+			continue
+		}
+		turns := tracker.round - enemy.sighting.round
+		for _, coord := range tracker.possiblePositions(id) {
+			pac := enemy.sighting.pac
+			pac.pos = coord
+			pac.abilityCooldown -= turns
+			if pac.abilityCooldown < 0 {
+				pac.abilityCooldown = 0
+			}
+			pac.speedTurnsLeft -= turns
+			if pac.speedTurnsLeft < 0 {
+				pac.speedTurnsLeft = 0
+			}
+			pacs = append(pacs, pac)
+		}
+	}
+	return pacs
+}
+
+// typeCounts returns how many of the enemy pacs still around are of each type, as we last saw them. Pacs seen dead, or that have vanished
+// from everywhere they could have been, are left out, and so are those we've only inferred from the map's symmetry, which may have
+// switched long before we get to see them
+func (tracker *EnemyTracker) typeCounts() map[PacType]int {
+	counts := make(map[PacType]int)
+	for _, enemy := range tracker.enemies {
+		if enemy.inferred {
+			continue
+		}
+		for _, possible := range enemy.possible {
+			if possible {
+				counts[enemy.sighting.pac.typeID]++
+				break
+			}
+		}
+	}
+	return counts
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEnemyTrackerSpreadsOutOfSight(t *testing.T) {
+	gameMap := BuildGameMap(ringMap)
+	tracker := newEnemyTracker(gameMap)
+	me := Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}
+	enemy := Pac{id: 1, pos: Coord{7, 1}, typeID: "SCISSORS", abilityCooldown: 5}
+
+	tracker.observe(GameData{0, gameMap, []int{0, 0}, []Pac{me, enemy}, nil})
+	if expected, actual := []Coord{{7, 1}}, tracker.possiblePositions(1); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected a visible enemy to be where we see it, but got %v", actual)
+	}
+
+	// row 1 is still in sight, so the enemy can only have gone down
+	tracker.observe(GameData{1, gameMap, []int{0, 0}, []Pac{me}, nil})
+	if expected, actual := []Coord{{7, 2}}, tracker.possiblePositions(1); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected the enemy to have gone out of sight, but got %v", actual)
+	}
+	tracker.observe(GameData{2, gameMap, []int{0, 0}, []Pac{me}, nil})
+	if expected, actual := []Coord{{7, 2}, {7, 3}}, tracker.possiblePositions(1); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected the enemy to have kept going, but got %v", actual)
+	}
+
+	if expected, actual := (enemySighting{enemy, 0}), tracker.enemies[1].sighting; expected != actual {
+		t.Errorf("expected the enemy to have been last seen as %+v, but got %+v", expected, actual)
+	}
+	if _, tracked := tracker.enemies[0]; tracked {
+		t.Errorf("expected an enemy we've never seen not to be tracked")
+	}
+}
+
+func TestEnemyTrackerSpreadsFasterWithSpeed(t *testing.T) {
+	gameMap := BuildGameMap(ringMap)
+	tracker := newEnemyTracker(gameMap)
+	me := Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}
+
+	tracker.observe(GameData{0, gameMap, []int{0, 0}, []Pac{me, {id: 1, pos: Coord{7, 1}, typeID: "SCISSORS"}}, nil})
+	tracker.observe(GameData{1, gameMap, []int{0, 0}, []Pac{me}, nil})
+	if expected, actual := []Coord{{7, 2}, {7, 3}}, tracker.possiblePositions(1); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected an enemy that can activate speed to have moved up to two cells, but got %v", actual)
+	}
+}
+
+func TestEnemyTrackerLurkingEnemies(t *testing.T) {
+	gameMap := BuildGameMap(ringMap)
+	tracker := newEnemyTracker(gameMap)
+	me := Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}
+	enemy := Pac{id: 1, pos: Coord{7, 1}, typeID: "PAPER", abilityCooldown: 9}
+
+	tracker.observe(GameData{0, gameMap, []int{0, 0}, []Pac{me, enemy}, nil})
+	if lurking := tracker.lurkingEnemies(recentSightingTurns); len(lurking) != 0 {
+		t.Errorf("expected enemies in sight not to be lurking, but got %v", lurking)
+	}
+
+	tracker.observe(GameData{1, gameMap, []int{0, 0}, []Pac{me}, nil})
+	// a turn later, its cooldown is a turn closer to being over
+	expected := enemy
+	expected.pos, expected.abilityCooldown = Coord{7, 2}, 8
+	if actual := tracker.lurkingEnemies(recentSightingTurns); !reflect.DeepEqual([]Pac{expected}, actual) {
+		t.Errorf("expected %+v to be lurking, but got %+v", expected, actual)
+	}
+
+	for round := 2; round <= 2+recentSightingTurns; round++ {
+		tracker.observe(GameData{round, gameMap, []int{0, 0}, []Pac{me}, nil})
+	}
+	if lurking := tracker.lurkingEnemies(recentSightingTurns); len(lurking) != 0 {
+		t.Errorf("expected an enemy seen long ago to be forgotten, but got %v", lurking)
+	}
+}
+
+func TestBotRemembersEnemiesOutOfSight(t *testing.T) {
+	gameMap := BuildGameMap(ringMap)
+	bot := DansLilHeuristicBot{}
+	bot.init(gameMap)
+	me := Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "PAPER"}
+
+	bot.update(GameData{0, gameMap, []int{0, 0}, []Pac{me, {id: 0, pos: Coord{7, 1}, typeID: "SCISSORS", abilityCooldown: 9}}, nil})
+	bot.update(GameData{1, gameMap, []int{0, 0}, []Pac{me}, nil})
+
+	if enemies := bot.enemies.lurkingEnemies(recentSightingTurns); len(enemies) != 1 || enemies[0].pos != (Coord{7, 2}) {
+		t.Errorf("expected the enemy that went out of sight to be considered a threat, but got %v", enemies)
+	}
+	// but it doesn't block the way, since it may well be somewhere else
+	if expected, actual := (map[Coord]Pac{me.pos: me}), bot.pacsByPos; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected only the pacs in sight to take up cells, but got %v", actual)
+	}
+}
+
+func TestEnemyTrackerTypeCounts(t *testing.T) {
+	gameMap := BuildGameMap(ringMap)
+	tracker := newEnemyTracker(gameMap)
+	me := Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}
+	enemies := []Pac{{id: 1, pos: Coord{7, 1}, typeID: "PAPER"}, {id: 2, pos: Coord{5, 1}, typeID: "PAPER"}, {id: 3, pos: Coord{3, 1}, typeID: "SCISSORS"}}
+
+	tracker.observe(GameData{1, gameMap, []int{0, 0}, append([]Pac{me}, enemies...), nil})
+	if expected, actual := map[PacType]int{"PAPER": 2, "SCISSORS": 1}, tracker.typeCounts(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected type counts %v, but got %v", expected, actual)
+	}
+
+	// an enemy that vanished from everywhere it could be was most likely eaten
+	for pos := range tracker.enemies[3].possible {
+		tracker.enemies[3].possible[pos] = false
+	}
+	if expected, actual := map[PacType]int{"PAPER": 2}, tracker.typeCounts(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected type counts %v, but got %v", expected, actual)
+	}
+}
+
+func TestEnemyTrackerForgetsDeadEnemies(t *testing.T) {
+	gameMap := BuildGameMap(ringMap)
+	tracker := newEnemyTracker(gameMap)
+	me := Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}
+	enemy := Pac{id: 1, pos: Coord{7, 1}, typeID: "SCISSORS", abilityCooldown: 5}
+	dead := Pac{id: 1, pos: Coord{7, 1}, typeID: deadTypeID}
+
+	tracker.observe(GameData{0, gameMap, []int{0, 0}, []Pac{me, enemy}, nil})
+	tracker.observe(GameData{1, gameMap, []int{0, 0}, []Pac{me, dead}, nil})
+	tracker.observe(GameData{2, gameMap, []int{0, 0}, []Pac{me}, nil})
+
+	if positions := tracker.possiblePositions(1); len(positions) != 0 {
+		t.Errorf("expected a dead enemy not to be anywhere, but got %v", positions)
+	}
+	if lurking := tracker.lurkingEnemies(recentSightingTurns); len(lurking) != 0 {
+		t.Errorf("expected a dead enemy not to be lurking, but got %v", lurking)
+	}
+	if counts := tracker.typeCounts(); len(counts) != 0 {
+		t.Errorf("expected a dead enemy not to be counted, but got %v", counts)
+	}
+	if expected, actual := (enemySighting{dead, 1}), tracker.enemies[1].sighting; expected != actual {
+		t.Errorf("expected the enemy to have been last seen dead as %+v, but got %+v", expected, actual)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+//-----------------------------------------------------------------------------------
+// Escape: where a pac should run to when enemies that can eat it come close
+//-----------------------------------------------------------------------------------
+
+const (
+	// escapeHorizon is how many turns of running away are planned
+	escapeHorizon = 4
+	// deadEndPenalty is how much less safe a refuge in a dead-end corridor is, in cells, since there's no way out once it's found
+	deadEndPenalty = 3
+	// caughtPenalty is how much less safe a refuge is if a threat can get to the pac on the way there
+	caughtPenalty = 100
+)
+
+// Escape is a way for a pac to run away
+type Escape struct {
+	// path holds the cells the pac walks through to its refuge, in order, its current cell excluded. It's empty for staying put
+	path []Coord
+	// safety is how far the refuge is from the nearest threat, in cells, less the penalties for dead ends and for getting caught on the
+	// way. It's negative if no refuge can be reached safely
+	safety int
+}
+
+// EscapePlanner looks for the safest cell a pac can run to
+type EscapePlanner struct {
+	gameMap   GameMap
+	distances *DistanceTable
+	maze      *MazeGraph
+}
+
+func newEscapePlanner(gameMap GameMap, distances *DistanceTable, maze *MazeGraph) *EscapePlanner {
+	return &EscapePlanner{gameMap: gameMap, distances: distances, maze: maze}
+}
+
+// plan returns the safest escape for pac from threats, among the cells it can reach within escapeHorizon turns at its current speed:
+// the one farthest from the nearest threat, as long as no threat can get in the way, preferably out of dead ends. Distances go through
+// tunnels across the edges of the map. Threats are assumed to speed up whenever they can. Ties go to the shortest path
+func (planner *EscapePlanner) plan(pac Pac, threats []Pac) Escape {
+	gm := planner.gameMap
+	threatTurns := make([][]int, len(threats))
+	for i, threat := range threats {
+		threatTurns[i] = arrivalTurns(threat, len(gm.cells))
+	}
+	// caught returns true if a threat can be on coord by the time pac gets there
+	caught := func(coord Coord, turn int) bool {
+		for i, threat := range threats {
+			if distance := planner.distances.distance(threat.pos, coord); distance != unreachable && threatTurns[i][distance] <= turn {
+				return true
+			}
+		}
+		return false
+	}
+
+	best := Escape{safety: -unreachable}
+	gm.ForEachFloorCell(func(_ int, refuge Coord) {
+		distance := planner.distances.distance(pac.pos, refuge)
+		if distance == unreachable || turnsToReach(pac, distance) > escapeHorizon {
+			return
+		}
+
+		var path []Coord
+		safe := true
+		for from, step := pac.pos, 1; from != refuge; step++ {
+			from = planner.distances.firstStep(from, refuge)
+			path = append(path, from)
+			safe = safe && !caught(from, turnsToReach(pac, step))
+		}
+
+		safety := unreachable
+		for _, threat := range threats {
+			if distance := planner.distances.distance(threat.pos, refuge); distance < safety {
+				safety = distance
+			}
+		}
+		if corridor, _, ok := planner.maze.corridorAt(refuge); ok && corridor.deadEnd {
+			safety -= deadEndPenalty
+		}
+		if !safe {
+			safety -= caughtPenalty
+		}
+		if safety > best.safety || (safety == best.safety && len(path) < len(best.path)) {
+			best = Escape{path, safety}
+		}
+	})
+	return best
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEscapePlanner(t *testing.T) {
+	tests := []struct {
+		name           string
+		cells          string
+		me             Pac
+		threat         Pac
+		expectedPath   []Coord
+		expectedSafety int
+	}{
+		{
+			name:           "run to the far end of the corridor",
+			cells:          longCorridorMap,
+			me:             Pac{pos: Coord{4, 1}, typeID: "ROCK"},
+			threat:         Pac{pos: Coord{7, 1}, typeID: "PAPER", abilityCooldown: 5},
+			expectedPath:   []Coord{{3, 1}, {2, 1}, {1, 1}},
+			expectedSafety: 6 - deadEndPenalty,
+		},
+		{
+			name:           "run through the tunnel",
+			cells:          "\n#########\n         \n#########",
+			me:             Pac{pos: Coord{1, 1}, typeID: "ROCK"},
+			threat:         Pac{pos: Coord{3, 1}, typeID: "PAPER", abilityCooldown: 5},
+			expectedPath:   []Coord{{0, 1}, {8, 1}},
+			expectedSafety: 4,
+		},
+		{
+			name: "stay out of dead ends",
+			cells: `
+########
+#    ###
+# ## ###
+#      #
+########`,
+			me:             Pac{pos: Coord{4, 3}, typeID: "ROCK"},
+			threat:         Pac{pos: Coord{1, 1}, typeID: "PAPER", abilityCooldown: 5},
+			expectedPath:   nil,
+			expectedSafety: 5,
+		},
+		{
+			name:           "don't get caught on the way",
+			cells:          longCorridorMap,
+			me:             Pac{pos: Coord{3, 1}, typeID: "ROCK"},
+			threat:         Pac{pos: Coord{5, 1}, typeID: "PAPER", speedTurnsLeft: 5},
+			expectedPath:   []Coord{{2, 1}},
+			expectedSafety: 3 - deadEndPenalty,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gameMap := BuildGameMap(tt.cells)
+			distances := newDistanceTable(gameMap)
+			planner := newEscapePlanner(gameMap, distances, newMazeGraph(gameMap))
+
+			escape := planner.plan(tt.me, []Pac{tt.threat})
+			if !reflect.DeepEqual(tt.expectedPath, escape.path) || tt.expectedSafety != escape.safety {
+				t.Errorf("expected path %v with safety %v, but got %v with safety %v", tt.expectedPath, tt.expectedSafety, escape.path, escape.safety)
+			}
+		})
+	}
+}
+
+func TestEscapePlannerWithoutThreatsStaysPut(t *testing.T) {
+	gameMap := BuildGameMap(corridorMap)
+	planner := newEscapePlanner(gameMap, newDistanceTable(gameMap), newMazeGraph(gameMap))
+
+	if escape := planner.plan(Pac{pos: Coord{3, 1}, typeID: "ROCK"}, nil); len(escape.path) != 0 {
+		t.Errorf("expected to stay put, but got %v", escape.path)
+	}
+}
@@ -128,3 +128,71 @@ func TestWrap(t *testing.T) {
 		}
 	}
 }
+
+func TestNeighborsAndKind(t *testing.T) {
+	gm := BuildGameMap(`
+#####
+  # #
+#   #
+#####`)
+	tests := []struct {
+		coord        Coord
+		neighbors    []Coord
+		expectedKind CellKind
+	}{
+		{Coord{0, 0}, []Coord{}, wallCell},
+		// the tunnel wraps around to the right edge, but that's a wall
+		{Coord{0, 1}, []Coord{{1, 1}}, deadEndCell},
+		{Coord{1, 1}, []Coord{{1, 2}, {0, 1}}, corridorCell},
+		{Coord{2, 2}, []Coord{{3, 2}, {1, 2}}, corridorCell},
+		{Coord{1, 2}, []Coord{{1, 1}, {2, 2}}, corridorCell},
+		{Coord{3, 1}, []Coord{{3, 2}}, deadEndCell},
+		{Coord{3, 2}, []Coord{{3, 1}, {2, 2}}, corridorCell},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.coord), func(t *testing.T) {
+			if expected, actual := tt.neighbors, gm.Neighbors(tt.coord); !reflect.DeepEqual(expected, actual) {
+				t.Errorf("expected neighbors %v, but got %v", expected, actual)
+			}
+			if expected, actual := tt.expectedKind, gm.Kind(tt.coord); expected != actual {
+				t.Errorf("expected kind %v, but got %v", expected, actual)
+			}
+		})
+	}
+
+	junction := BuildGameMap(`
+#####
+#   #
+## ##
+#####`)
+	if expected, actual := junctionCell, junction.Kind(Coord{2, 1}); expected != actual {
+		t.Errorf("expected a junction, but got %v", actual)
+	}
+	if expected, actual := 3, junction.Degree(Coord{2, 1}); expected != actual {
+		t.Errorf("expected degree %v, but got %v", expected, actual)
+	}
+}
+
+func TestNeighborsWithoutPrecomputedGraph(t *testing.T) {
+	built := BuildGameMap(corridorMap)
+	bare := GameMap{width: built.width, height: built.height, cells: built.cells}
+	for pos := range built.cells {
+		if expected, actual := built.NeighborPositions(pos), bare.NeighborPositions(pos); !reflect.DeepEqual(expected, actual) {
+			t.Errorf("expected the neighbors of %v to be %v, but got %v", built.GetCoord(pos), expected, actual)
+		}
+	}
+}
+
+func TestForEachFloorCell(t *testing.T) {
+	gm := BuildGameMap(corridorMap)
+	var visited []Coord
+	gm.ForEachFloorCell(func(pos int, coord Coord) {
+		if gm.GetAbsolutePosition(coord) != pos {
+			t.Errorf("expected %v to be at position %v", coord, pos)
+		}
+		visited = append(visited, coord)
+	})
+	if expected := []Coord{{1, 1}, {2, 1}, {3, 1}, {4, 1}, {5, 1}}; !reflect.DeepEqual(expected, visited) {
+		t.Errorf("expected floor cells %v, but got %v", expected, visited)
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"math/rand"
+)
+
+//-----------------------------------------------------------------------------------
+// Map generator: symmetric, wrapping mazes like the ones used in the contest
+//-----------------------------------------------------------------------------------
+
+const (
+	minMapWidth, maxMapWidth   = 28, 35
+	minMapHeight, maxMapHeight = 10, 17
+	minPacsPerPlayer           = 2
+	maxPacsPerPlayer           = 5
+	// superPelletsPerSide is the number of super pellets on each half of the map
+	superPelletsPerSide = 2
+)
+
+// MapGeneratorOptions tunes the maps made by generateMap. The zero value is a sensible default
+type MapGeneratorOptions struct {
+	// maxDeadEnds is the number of dead-end cells that may be left in the maze
+	maxDeadEnds int
+	// pacsPerPlayer is the number of pacs each player starts with, or 0 to pick between 2 and 5 at random
+	pacsPerPlayer int
+}
+
+// GeneratedMap is a map along with the starting position of a game played on it
+type GeneratedMap struct {
+	// seed is the seed the map was generated from: generating again with the same seed and options yields the same map
+	seed    int64
+	gameMap GameMap
+	// pacs contains both players' pacs. Player 0's pacs (mine is true) start on the left half, player 1's are their mirror image
+	pacs         []Pac
+	superPellets []Coord
+}
+
+// newReferee creates a referee for a game starting on this map, with a pellet on every free floor cell
+func (generated GeneratedMap) newReferee() *Referee {
+	return newReferee(generated.gameMap, generated.pacs, initialPellets(generated.gameMap, generated.pacs, generated.superPellets))
+}
+
+// mapGenerator carves a maze into a grid of walls. The maze's "rooms" are the cells with odd coordinates, the cells in between are
+// the walls that get knocked down to connect them. Only the left half is carved, the right half is always kept as its mirror image.
+type mapGenerator struct {
+	rng           *rand.Rand
+	width, height int
+	cells         []Cell
+}
+
+// generateMap generates a horizontally mirrored maze that wraps around through tunnels on its left and right edges
+func generateMap(seed int64, options MapGeneratorOptions) GeneratedMap {
+	rng := rand.New(rand.NewSource(seed))
+	// rooms sit on odd rows between the top and bottom walls, which requires an odd height
+	height := minMapHeight + 1 + 2*rng.Intn((maxMapHeight-minMapHeight)/2+1)
+	width := minMapWidth + rng.Intn(maxMapWidth-minMapWidth+1)
+
+	gen := &mapGenerator{rng: rng, width: width, height: height, cells: make([]Cell, width*height)}
+	for i := range gen.cells {
+		gen.cells[i] = Cell{'#'}
+	}
+	gen.carve()
+	gen.connectHalves()
+	gen.digTunnels()
+	gen.braid(options.maxDeadEnds)
+
+	generated := GeneratedMap{seed: seed, gameMap: newGameMap(width, height, gen.cells)}
+	pacCount := options.pacsPerPlayer
+	if pacCount == 0 {
+		pacCount = minPacsPerPlayer + rng.Intn(maxPacsPerPlayer-minPacsPerPlayer+1)
+	}
+	spots := gen.leftFloorCells()
+	rng.Shuffle(len(spots), func(i, j int) { spots[i], spots[j] = spots[j], spots[i] })
+
+	firstType := rng.Intn(len(pacTypes))
+	for id := 0; id < pacCount; id++ {
+		pos, typeID := spots[id], pacTypes[(firstType+id)%len(pacTypes)]
+		generated.pacs = append(generated.pacs, Pac{id: id, mine: true, pos: pos, typeID: typeID})
+		generated.pacs = append(generated.pacs, Pac{id: id, pos: gen.mirror(pos), typeID: typeID})
+	}
+	for _, pos := range spots[pacCount : pacCount+superPelletsPerSide] {
+		generated.superPellets = append(generated.superPellets, pos, gen.mirror(pos))
+	}
+	return generated
+}
+
+// lastColumn returns the rightmost column of the left half. On maps of odd width, that's the middle column, which is its own mirror
+func (gen *mapGenerator) lastColumn() int {
+	return (gen.width - 1) / 2
+}
+
+func (gen *mapGenerator) mirror(pos Coord) Coord {
+	return Coord{gen.width - 1 - pos.x, pos.y}
+}
+
+func (gen *mapGenerator) isFloor(pos Coord) bool {
+	return gen.cells[pos.x+pos.y*gen.width].value == ' '
+}
+
+// open turns the cell at pos and its mirror image into floor
+func (gen *mapGenerator) open(pos Coord) {
+	gen.cells[pos.x+pos.y*gen.width] = Cell{' '}
+	mirror := gen.mirror(pos)
+	gen.cells[mirror.x+mirror.y*gen.width] = Cell{' '}
+}
+
+// openRow opens every cell of row y from x to its mirror image, joining both halves of the map
+func (gen *mapGenerator) openRow(x, y int) {
+	for ; x <= gen.lastColumn(); x++ {
+		gen.open(Coord{x, y})
+	}
+}
+
+// lastRoomColumn returns the column of the rightmost rooms of the left half
+func (gen *mapGenerator) lastRoomColumn() int {
+	return gen.lastColumn() - 1 + gen.lastColumn()%2
+}
+
+// carve digs a perfect maze through the rooms of the left half, with a randomized depth-first search
+func (gen *mapGenerator) carve() {
+	start := Coord{1, 1 + 2*gen.rng.Intn((gen.height-1)/2)}
+	gen.open(start)
+	stack := []Coord{start}
+	for len(stack) > 0 {
+		room := stack[len(stack)-1]
+		var unvisited []Coord
+		for _, next := range gen.adjacentRooms(room) {
+			if !gen.isFloor(next) {
+				unvisited = append(unvisited, next)
+			}
+		}
+		if len(unvisited) == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		next := unvisited[gen.rng.Intn(len(unvisited))]
+		gen.open(Coord{(room.x + next.x) / 2, (room.y + next.y) / 2})
+		gen.open(next)
+		stack = append(stack, next)
+	}
+}
+
+// adjacentRooms returns the rooms of the left half two cells away from room, in each orthogonal direction
+func (gen *mapGenerator) adjacentRooms(room Coord) []Coord {
+	var rooms []Coord
+	for _, d := range []Coord{{0, -2}, {2, 0}, {0, 2}, {-2, 0}} {
+		next := Coord{room.x + d.x, room.y + d.y}
+		if next.x >= 1 && next.x <= gen.lastRoomColumn() && next.y >= 1 && next.y <= gen.height-2 {
+			rooms = append(rooms, next)
+		}
+	}
+	return rooms
+}
+
+// connectHalves opens a few rows through the middle of the map, since the maze itself never crosses it
+func (gen *mapGenerator) connectHalves() {
+	rows := (gen.height - 1) / 2
+	for _, i := range gen.rng.Perm(rows)[:1+rows/3] {
+		gen.openRow(gen.lastRoomColumn()+1, 1+2*i)
+	}
+}
+
+// digTunnels opens the left and right edges of one or two rows, letting pacs wrap around the map
+func (gen *mapGenerator) digTunnels() {
+	rows := (gen.height - 1) / 2
+	for _, i := range gen.rng.Perm(rows)[:1+gen.rng.Intn(2)] {
+		gen.open(Coord{0, 1 + 2*i})
+	}
+}
+
+// degree returns the number of floor cells a pac at pos can move to, wrapping around the edges of the map
+func (gen *mapGenerator) degree(pos Coord) (degree int) {
+	for _, d := range []Coord{{0, -1}, {1, 0}, {0, 1}, {-1, 0}} {
+		x, y := (pos.x+d.x+gen.width)%gen.width, (pos.y+d.y+gen.height)%gen.height
+		if gen.isFloor(Coord{x, y}) {
+			degree++
+		}
+	}
+	return
+}
+
+// deadEnds returns the dead-end cells of the left half, and the total number of dead-end cells on the map
+func (gen *mapGenerator) deadEnds() (left []Coord, total int) {
+	for y := 0; y < gen.height; y++ {
+		for x := 0; x < gen.width; x++ {
+			pos := Coord{x, y}
+			if gen.isFloor(pos) && gen.degree(pos) <= 1 {
+				total++
+				if x <= gen.lastColumn() {
+					left = append(left, pos)
+				}
+			}
+		}
+	}
+	return
+}
+
+// braid knocks down walls at dead ends, turning them into loops, until no more than maxDeadEnds are left, or none of those left has a
+// wall that can be knocked down
+func (gen *mapGenerator) braid(maxDeadEnds int) {
+	// closed holds the dead ends found to have no wall to knock down
+	closed := make(map[Coord]bool)
+	for {
+		var deadEnds []Coord
+		left, total := gen.deadEnds()
+		for _, deadEnd := range left {
+			if !closed[deadEnd] {
+				deadEnds = append(deadEnds, deadEnd)
+			}
+		}
+		if total <= maxDeadEnds || len(deadEnds) == 0 {
+			return
+		}
+		deadEnd := deadEnds[gen.rng.Intn(len(deadEnds))]
+
+		var walls []Coord
+		for _, next := range gen.adjacentRooms(deadEnd) {
+			if wall := (Coord{(deadEnd.x + next.x) / 2, (deadEnd.y + next.y) / 2}); !gen.isFloor(wall) {
+				walls = append(walls, wall)
+			}
+		}
+		if deadEnd.x == gen.lastRoomColumn() && deadEnd.x < gen.lastColumn() {
+			// the rightmost rooms can also be joined to their mirror image through the middle of the map
+			walls = append(walls, Coord{deadEnd.x + 1, deadEnd.y})
+		}
+		if len(walls) == 0 {
+			closed[deadEnd] = true
+			continue
+		}
+		wall := walls[gen.rng.Intn(len(walls))]
+		if wall.x > gen.lastRoomColumn() {
+			gen.openRow(wall.x, wall.y)
+		} else {
+			gen.open(wall)
+		}
+	}
+}
+
+// leftFloorCells returns the floor cells strictly left of the middle of the map, which all have a distinct mirror image
+func (gen *mapGenerator) leftFloorCells() []Coord {
+	var cells []Coord
+	for y := 0; y < gen.height; y++ {
+		for x := 0; x < gen.width; x++ {
+			if pos := (Coord{x, y}); x < gen.mirror(pos).x && gen.isFloor(pos) {
+				cells = append(cells, pos)
+			}
+		}
+	}
+	return cells
+}
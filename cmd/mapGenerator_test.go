@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// reachableCells returns every floor cell a pac at from can walk to, wrapping around the edges of the map
+func reachableCells(gm GameMap, from Coord) map[Coord]bool {
+	reached := map[Coord]bool{from: true}
+	queue := []Coord{from}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, d := range []Coord{{0, -1}, {1, 0}, {0, 1}, {-1, 0}} {
+			next := gm.Wrap(Coord{node.x + d.x, node.y + d.y})
+			if !reached[next] && gm.GetCell(next).value == ' ' {
+				reached[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return reached
+}
+
+func TestGenerateMapIsReproducible(t *testing.T) {
+	options := MapGeneratorOptions{maxDeadEnds: 2}
+	first, second := generateMap(42, options), generateMap(42, options)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("expected the same seed to generate the same map, but got\n%v\nand\n%v",
+			strings.Join(first.gameMap.rows(), "\n"), strings.Join(second.gameMap.rows(), "\n"))
+	}
+	if other := generateMap(43, options); reflect.DeepEqual(first.gameMap, other.gameMap) {
+		t.Errorf("expected different seeds to generate different maps")
+	}
+}
+
+func TestGenerateMap(t *testing.T) {
+	for seed := int64(0); seed < 50; seed++ {
+		options := MapGeneratorOptions{maxDeadEnds: int(seed % 5)}
+		generated := generateMap(seed, options)
+		gm := generated.gameMap
+
+		t.Run(fmt.Sprint(seed), func(t *testing.T) {
+			defer func() {
+				if t.Failed() {
+					t.Logf("map:\n%v", strings.Join(gm.rows(), "\n"))
+				}
+			}()
+
+			if gm.width < minMapWidth || gm.width > maxMapWidth || gm.height < minMapHeight || gm.height > maxMapHeight {
+				t.Errorf("expected a size within %vx%v and %vx%v, but got %vx%v", minMapWidth, minMapHeight, maxMapWidth, maxMapHeight, gm.width, gm.height)
+			}
+
+			tunnels, deadEnds := 0, 0
+			var floor []Coord
+			for pos, cell := range gm.cells {
+				coord := gm.GetCoord(pos)
+				if mirrored := gm.GetCell(Coord{gm.width - 1 - coord.x, coord.y}); cell != mirrored {
+					t.Fatalf("expected %v to mirror (%v,%v)", coord, gm.width-1-coord.x, coord.y)
+				}
+				if cell.value != ' ' {
+					continue
+				}
+				floor = append(floor, coord)
+				if coord.y == 0 || coord.y == gm.height-1 {
+					t.Errorf("expected the top and bottom rows to be walls, but %v is floor", coord)
+				}
+				if coord.x == 0 {
+					tunnels++
+				}
+				exits := 0
+				for _, d := range []Coord{{0, -1}, {1, 0}, {0, 1}, {-1, 0}} {
+					if gm.GetCell(gm.Wrap(Coord{coord.x + d.x, coord.y + d.y})).value == ' ' {
+						exits++
+					}
+				}
+				if exits <= 1 {
+					deadEnds++
+				}
+			}
+			if tunnels == 0 {
+				t.Errorf("expected at least one tunnel wrapping around the map")
+			}
+			if deadEnds > options.maxDeadEnds {
+				t.Errorf("expected at most %v dead ends, but got %v", options.maxDeadEnds, deadEnds)
+			}
+			if reached := reachableCells(gm, floor[0]); len(reached) != len(floor) {
+				t.Errorf("expected all %v floor cells to be connected, but only reached %v", len(floor), len(reached))
+			}
+
+			pacCount := len(generated.pacs) / 2
+			if pacCount < minPacsPerPlayer || pacCount > maxPacsPerPlayer {
+				t.Errorf("expected between %v and %v pacs per player, but got %v", minPacsPerPlayer, maxPacsPerPlayer, pacCount)
+			}
+			occupied := make(map[Coord]bool)
+			for i := 0; i < len(generated.pacs); i += 2 {
+				mine, theirs := generated.pacs[i], generated.pacs[i+1]
+				expected := Pac{id: mine.id, pos: Coord{gm.width - 1 - mine.pos.x, mine.pos.y}, typeID: mine.typeID}
+				if !mine.mine || theirs != expected {
+					t.Errorf("expected %+v to be mirrored by %+v, but got %+v", mine, expected, theirs)
+				}
+				occupied[mine.pos], occupied[theirs.pos] = true, true
+			}
+			if len(generated.superPellets) != 2*superPelletsPerSide {
+				t.Errorf("expected %v super pellets, but got %v", 2*superPelletsPerSide, generated.superPellets)
+			}
+			for _, pos := range append(generated.superPellets, pacPositions(generated.pacs)...) {
+				if gm.GetCell(pos).value != ' ' {
+					t.Errorf("expected %v to be floor", pos)
+				}
+			}
+			for _, pos := range generated.superPellets {
+				if occupied[pos] {
+					t.Errorf("expected super pellet %v not to start under a pac", pos)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateMapBraidsDownToMaxDeadEnds(t *testing.T) {
+	for seed := int64(0); seed < 500; seed++ {
+		options := MapGeneratorOptions{maxDeadEnds: int(seed % 3)}
+		gm := generateMap(seed, options).gameMap
+		total := 0
+		for _, pos := range gm.FloorPositions() {
+			if gm.Degree(gm.GetCoord(pos)) <= 1 {
+				total++
+			}
+		}
+		if total > options.maxDeadEnds {
+			t.Errorf("seed %v: expected at most %v dead ends, but got %v", seed, options.maxDeadEnds, total)
+		}
+	}
+}
+
+func TestGenerateMapPacsPerPlayer(t *testing.T) {
+	generated := generateMap(7, MapGeneratorOptions{pacsPerPlayer: 5})
+	if expected, actual := 10, len(generated.pacs); expected != actual {
+		t.Errorf("expected %v pacs, but got %v", expected, actual)
+	}
+}
+
+func pacPositions(pacs []Pac) []Coord {
+	positions := make([]Coord, len(pacs))
+	for i, pac := range pacs {
+		positions[i] = pac.pos
+	}
+	return positions
+}
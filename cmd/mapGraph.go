@@ -0,0 +1,111 @@
+package main
+
+//-----------------------------------------------------------------------------------
+// Map graph: which cells a pac can move to from each floor cell
+//-----------------------------------------------------------------------------------
+
+// CellKind classifies a cell by the number of ways out of it
+type CellKind int
+
+const (
+	wallCell CellKind = iota
+	// deadEndCell is a floor cell with a single way out (or none at all)
+	deadEndCell
+	// corridorCell is a floor cell with exactly two ways out
+	corridorCell
+	// junctionCell is a floor cell with three or four ways out
+	junctionCell
+)
+
+// orthogonalDirections are the moves a pac can make, in the order neighbors are listed: up, right, down, left
+var orthogonalDirections = []Coord{{0, -1}, {1, 0}, {0, 1}, {-1, 0}}
+
+// newGameMap returns a map of the given size and cells, with the movement graph precomputed. Cells are in row order, like
+// GetAbsolutePosition
+func newGameMap(width, height int, cells []Cell) GameMap {
+	gm := GameMap{width: width, height: height, cells: cells}
+	gm.adjacency = make([][]int, len(cells))
+	for pos := range cells {
+		gm.adjacency[pos] = gm.computeNeighbors(pos)
+	}
+	return gm
+}
+
+// computeNeighbors returns the absolute positions of the floor cells next to pos, wrapping around the edges of the map. Walls have no
+// neighbors
+func (gm GameMap) computeNeighbors(pos int) []int {
+	if gm.cells[pos].value != ' ' {
+		return nil
+	}
+	coord := gm.GetCoord(pos)
+	var neighbors []int
+	for _, d := range orthogonalDirections {
+		next := gm.GetAbsolutePosition(gm.Wrap(Coord{coord.x + d.x, coord.y + d.y}))
+		if next != pos && gm.cells[next].value == ' ' {
+			neighbors = append(neighbors, next)
+		}
+	}
+	return neighbors
+}
+
+// NeighborPositions returns the absolute positions of the cells a pac standing at absolute position pos can move to in one step
+func (gm GameMap) NeighborPositions(pos int) []int {
+	if gm.adjacency == nil {
+		// the map was built without newGameMap, work it out on the fly
+		return gm.computeNeighbors(pos)
+	}
+	return gm.adjacency[pos]
+}
+
+// Neighbors returns the cells a pac standing at coord can move to in one step
+func (gm GameMap) Neighbors(coord Coord) []Coord {
+	positions := gm.NeighborPositions(gm.GetAbsolutePosition(gm.Wrap(coord)))
+	neighbors := make([]Coord, len(positions))
+	for i, pos := range positions {
+		neighbors[i] = gm.GetCoord(pos)
+	}
+	return neighbors
+}
+
+// Degree returns the number of ways out of coord
+func (gm GameMap) Degree(coord Coord) int {
+	return len(gm.NeighborPositions(gm.GetAbsolutePosition(gm.Wrap(coord))))
+}
+
+// Kind classifies coord as a wall, dead end, corridor or junction
+func (gm GameMap) Kind(coord Coord) CellKind {
+	if !gm.IsFloor(coord) {
+		return wallCell
+	}
+	switch degree := gm.Degree(coord); {
+	case degree <= 1:
+		return deadEndCell
+	case degree == 2:
+		return corridorCell
+	default:
+		return junctionCell
+	}
+}
+
+// IsFloor returns true if a pac can stand at coord
+func (gm GameMap) IsFloor(coord Coord) bool {
+	return gm.GetCell(gm.Wrap(coord)).value == ' '
+}
+
+// FloorPositions returns the absolute position of every floor cell, in row order
+func (gm GameMap) FloorPositions() []int {
+	var floor []int
+	for pos, cell := range gm.cells {
+		if cell.value == ' ' {
+			floor = append(floor, pos)
+		}
+	}
+	return floor
+}
+
+// ForEachFloorCell calls f with the absolute position and coordinates of every floor cell, in row order
+func (gm GameMap) ForEachFloorCell(f func(pos int, coord Coord)) {
+	for _, pos := range gm.FloorPositions() {
+		f(pos, gm.GetCoord(pos))
+	}
+}
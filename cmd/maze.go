@@ -0,0 +1,187 @@
+package main
+
+//-----------------------------------------------------------------------------------
+// Maze: the map compressed into junctions joined by corridors
+//-----------------------------------------------------------------------------------
+
+// noJunction is the junction id at the end of a corridor that stops at a dead end instead of a junction
+const noJunction = -1
+
+// Junction is a floor cell with three or more ways out
+type Junction struct {
+	id  int
+	pos Coord
+	// corridors holds the ids of the corridors leaving the junction, one per way out
+	corridors []int
+}
+
+// Corridor is a run of floor cells with two ways out (or dead ends) between two junctions. A corridor between two neighboring
+// junctions has no cells
+type Corridor struct {
+	id int
+	// cells holds the corridor's cells in order, walking from ends[0] to ends[1]. Junctions aren't included
+	cells []Coord
+	// ends holds the ids of the junctions at each end of the corridor, or noJunction for a dead end. Both ends are noJunction for a
+	// maze without any junction
+	ends [2]int
+	// length is the number of moves it takes to walk from one end of the corridor to the other, junctions included
+	length int
+	// deadEnd is true if the corridor only has one way in
+	deadEnd bool
+	// wraps is true if the corridor goes through a tunnel across the edge of the map
+	wraps bool
+}
+
+// MazeGraph is the map seen as a graph of junctions connected by corridors
+type MazeGraph struct {
+	gameMap   GameMap
+	junctions []Junction
+	corridors []Corridor
+	// junctionIDs holds the id of the junction at each absolute position, or noJunction
+	junctionIDs []int
+	// corridorIDs holds the id of the corridor at each absolute position, or -1 for walls and junctions
+	corridorIDs []int
+	// offsets holds the index of each absolute position in its corridor's cells
+	offsets []int
+}
+
+// newMazeGraph decomposes the floor of gameMap into junctions and the corridors between them
+func newMazeGraph(gameMap GameMap) *MazeGraph {
+	size := len(gameMap.cells)
+	graph := &MazeGraph{gameMap: gameMap, junctionIDs: make([]int, size), corridorIDs: make([]int, size), offsets: make([]int, size)}
+	for pos := range gameMap.cells {
+		graph.junctionIDs[pos], graph.corridorIDs[pos] = noJunction, -1
+	}
+	gameMap.ForEachFloorCell(func(pos int, coord Coord) {
+		if gameMap.Kind(coord) == junctionCell {
+			graph.junctionIDs[pos] = len(graph.junctions)
+			graph.junctions = append(graph.junctions, Junction{id: len(graph.junctions), pos: coord})
+		}
+	})
+
+	// walk every way out of every junction, unless it's a corridor we already walked from its other end
+	linked := make(map[[2]int]bool)
+	for _, junction := range graph.junctions {
+		from := gameMap.GetAbsolutePosition(junction.pos)
+		for _, next := range gameMap.NeighborPositions(from) {
+			if graph.corridorIDs[next] >= 0 {
+				continue
+			}
+			if graph.junctionIDs[next] != noJunction {
+				// neighboring junctions are joined by an empty corridor, which must only be added once
+				if linked[[2]int{next, from}] {
+					continue
+				}
+				linked[[2]int{from, next}] = true
+			}
+			graph.addCorridor(from, next)
+		}
+	}
+
+	// whatever is left belongs to parts of the maze without any junction: a single corridor, or a loop
+	gameMap.ForEachFloorCell(func(pos int, coord Coord) {
+		if graph.junctionIDs[pos] != noJunction || graph.corridorIDs[pos] >= 0 {
+			return
+		}
+		start := pos
+		for prev, cur := -1, pos; ; {
+			if gameMap.Degree(gameMap.GetCoord(cur)) <= 1 {
+				// start from the dead end, so that the corridor is listed from one end to the other
+				start = cur
+				break
+			}
+			next := otherNeighbor(gameMap, cur, prev)
+			if next == pos {
+				break
+			}
+			prev, cur = cur, next
+		}
+		graph.addCorridor(noJunction, start)
+	})
+	return graph
+}
+
+// addCorridor walks the corridor starting at absolute position start, coming from the junction at absolute position from (or
+// noJunction), until it reaches a junction, a dead end, or loops back on itself
+func (graph *MazeGraph) addCorridor(from, start int) {
+	gameMap := graph.gameMap
+	corridor := Corridor{id: len(graph.corridors), ends: [2]int{noJunction, noJunction}}
+	if from != noJunction {
+		corridor.ends[0] = graph.junctionIDs[from]
+	}
+	path := []int{}
+	if from != noJunction {
+		path = append(path, from)
+	}
+
+	loop := false
+	for prev, cur := from, start; ; {
+		if junctionID := graph.junctionIDs[cur]; junctionID != noJunction {
+			corridor.ends[1] = junctionID
+			path = append(path, cur)
+			break
+		}
+		if graph.corridorIDs[cur] == corridor.id {
+			loop = true
+			path = append(path, cur)
+			break
+		}
+		graph.corridorIDs[cur], graph.offsets[cur] = corridor.id, len(corridor.cells)
+		corridor.cells = append(corridor.cells, gameMap.GetCoord(cur))
+		path = append(path, cur)
+		next := otherNeighbor(gameMap, cur, prev)
+		if next < 0 {
+			break
+		}
+		prev, cur = cur, next
+	}
+
+	corridor.length = len(path) - 1
+	corridor.deadEnd = !loop && (corridor.ends[0] == noJunction || corridor.ends[1] == noJunction)
+	for i := 1; i < len(path); i++ {
+		a, b := gameMap.GetCoord(path[i-1]), gameMap.GetCoord(path[i])
+		if abs(a.x-b.x) > 1 || abs(a.y-b.y) > 1 {
+			corridor.wraps = true
+		}
+	}
+	for _, end := range corridor.ends {
+		if end != noJunction {
+			graph.junctions[end].corridors = append(graph.junctions[end].corridors, corridor.id)
+		}
+	}
+	graph.corridors = append(graph.corridors, corridor)
+}
+
+// otherNeighbor returns the first neighbor of pos that isn't prev, or -1 if there's none
+func otherNeighbor(gameMap GameMap, pos, prev int) int {
+	for _, next := range gameMap.NeighborPositions(pos) {
+		if next != prev {
+			return next
+		}
+	}
+	return -1
+}
+
+// corridorAt returns the corridor coord belongs to, and the index of coord in its cells. ok is false for walls and junctions
+func (graph *MazeGraph) corridorAt(coord Coord) (corridor *Corridor, offset int, ok bool) {
+	pos := graph.gameMap.GetAbsolutePosition(graph.gameMap.Wrap(coord))
+	if id := graph.corridorIDs[pos]; id >= 0 {
+		return &graph.corridors[id], graph.offsets[pos], true
+	}
+	return nil, 0, false
+}
+
+// junctionAt returns the junction at coord, or false if coord isn't a junction
+func (graph *MazeGraph) junctionAt(coord Coord) (*Junction, bool) {
+	if id := graph.junctionIDs[graph.gameMap.GetAbsolutePosition(graph.gameMap.Wrap(coord))]; id != noJunction {
+		return &graph.junctions[id], true
+	}
+	return nil, false
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestMazeGraphDeadEnds(t *testing.T) {
+	gameMap := BuildGameMap(`
+#######
+### ###
+#     #
+### ###
+#######`)
+	graph := newMazeGraph(gameMap)
+
+	if expected, actual := []Junction{{0, Coord{3, 2}, []int{0, 1, 2, 3}}}, graph.junctions; !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("expected junctions %v, but got %v", expected, actual)
+	}
+	expected := []Corridor{
+		{id: 0, cells: []Coord{{3, 1}}, ends: [2]int{0, noJunction}, length: 1, deadEnd: true},
+		{id: 1, cells: []Coord{{4, 2}, {5, 2}}, ends: [2]int{0, noJunction}, length: 2, deadEnd: true},
+		{id: 2, cells: []Coord{{3, 3}}, ends: [2]int{0, noJunction}, length: 1, deadEnd: true},
+		{id: 3, cells: []Coord{{2, 2}, {1, 2}}, ends: [2]int{0, noJunction}, length: 2, deadEnd: true},
+	}
+	if actual := graph.corridors; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected corridors %+v, but got %+v", expected, actual)
+	}
+
+	if corridor, offset, ok := graph.corridorAt(Coord{1, 2}); !ok || corridor.id != 3 || offset != 1 {
+		t.Errorf("expected (1,2) to be cell 1 of corridor 3, but got %+v, %v, %v", corridor, offset, ok)
+	}
+	if _, _, ok := graph.corridorAt(Coord{3, 2}); ok {
+		t.Errorf("expected a junction not to belong to a corridor")
+	}
+	if junction, ok := graph.junctionAt(Coord{3, 2}); !ok || junction.id != 0 {
+		t.Errorf("expected junction 0 at (3,2), but got %+v", junction)
+	}
+	if _, ok := graph.junctionAt(Coord{0, 0}); ok {
+		t.Errorf("expected a wall not to be a junction")
+	}
+}
+
+func TestMazeGraphWithoutJunctions(t *testing.T) {
+	tests := []struct {
+		description string
+		gameMap     GameMap
+		expected    Corridor
+	}{
+		{"straight corridor", BuildGameMap(corridorMap),
+			Corridor{cells: []Coord{{1, 1}, {2, 1}, {3, 1}, {4, 1}, {5, 1}}, ends: [2]int{noJunction, noJunction}, length: 4, deadEnd: true}},
+		{"loop", BuildGameMap(`
+#####
+#   #
+# # #
+#   #
+#####`),
+			Corridor{cells: []Coord{{1, 1}, {2, 1}, {3, 1}, {3, 2}, {3, 3}, {2, 3}, {1, 3}, {1, 2}}, ends: [2]int{noJunction, noJunction}, length: 8}},
+		{"tunnel", BuildGameMap(`
+#####
+  #  
+#####`),
+			Corridor{cells: []Coord{{1, 1}, {0, 1}, {4, 1}, {3, 1}}, ends: [2]int{noJunction, noJunction}, length: 3, deadEnd: true, wraps: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			graph := newMazeGraph(tt.gameMap)
+			if len(graph.junctions) != 0 {
+				t.Errorf("expected no junctions, but got %v", graph.junctions)
+			}
+			if expected, actual := []Corridor{tt.expected}, graph.corridors; !reflect.DeepEqual(expected, actual) {
+				t.Errorf("expected corridors %+v, but got %+v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestMazeGraphNeighboringJunctions(t *testing.T) {
+	graph := newMazeGraph(BuildGameMap(`
+######
+##  ##
+#    #
+##  ##
+######`))
+
+	if expected, actual := 2, len(graph.junctions); expected != actual {
+		t.Fatalf("expected %v junctions, but got %v", expected, graph.junctions)
+	}
+	empty := 0
+	for _, corridor := range graph.corridors {
+		if len(corridor.cells) == 0 {
+			empty++
+			if corridor.ends != [2]int{0, 1} || corridor.length != 1 || corridor.deadEnd {
+				t.Errorf("expected an empty corridor of length 1 between both junctions, but got %+v", corridor)
+			}
+		}
+	}
+	if empty != 1 {
+		t.Errorf("expected a single empty corridor, but got %v in %+v", empty, graph.corridors)
+	}
+	if expected, actual := 5, len(graph.corridors); expected != actual {
+		t.Errorf("expected %v corridors, but got %+v", expected, graph.corridors)
+	}
+}
+
+func TestMazeGraphCoversGeneratedMaps(t *testing.T) {
+	for seed := int64(0); seed < 10; seed++ {
+		gameMap := generateMap(seed, MapGeneratorOptions{maxDeadEnds: int(seed % 3)}).gameMap
+		graph := newMazeGraph(gameMap)
+		t.Run(fmt.Sprint(seed), func(t *testing.T) {
+			for _, junction := range graph.junctions {
+				if expected, actual := gameMap.Degree(junction.pos), len(junction.corridors); expected != actual {
+					t.Errorf("expected junction %v to have %v corridors, but got %v", junction.pos, expected, actual)
+				}
+			}
+			gameMap.ForEachFloorCell(func(pos int, coord Coord) {
+				_, isJunction := graph.junctionAt(coord)
+				corridor, offset, inCorridor := graph.corridorAt(coord)
+				if isJunction == inCorridor {
+					t.Errorf("expected %v to be either a junction or in a corridor", coord)
+				} else if inCorridor && corridor.cells[offset] != coord {
+					t.Errorf("expected %v to be cell %v of corridor %+v", coord, offset, corridor)
+				}
+			})
+		})
+	}
+}
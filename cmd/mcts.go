@@ -0,0 +1,464 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+//-----------------------------------------------------------------------------------
+// MCTS agent: searches our pacs' joint moves with decoupled UCT over a simplified model of the game
+//-----------------------------------------------------------------------------------
+
+const (
+	// defaultMCTSSearchTime is how long the agent searches when it isn't given a deadline, which fits in the arena's 50ms
+	defaultMCTSSearchTime = 40 * time.Millisecond
+	// mctsTreeDepth is how many turns deep the search tree grows. Past that, iterations play random moves
+	mctsTreeDepth = 4
+	// mctsHorizon is how many turns every iteration simulates
+	mctsHorizon = 12
+	// mctsDiscount is how much less a point is worth for every turn it takes to score it
+	mctsDiscount = 0.9
+	// mctsExploration weighs exploring moves tried less often against exploiting the best ones so far
+	mctsExploration = 2.0
+	// mctsDeathValue is how many points losing a pac (or eating an enemy) is worth
+	mctsDeathValue = 15.0
+)
+
+// mctsMove is what a pac does during a simulated turn
+type mctsMove int
+
+const (
+	mctsStay mctsMove = iota
+	// mctsUp through mctsLeft move one cell in the direction of orthogonalDirections (two cells with speed)
+	mctsUp
+	mctsRight
+	mctsDown
+	mctsLeft
+	mctsSpeed
+	// mctsSwitch is switching to pacTypes[0], followed by the other types
+	mctsSwitch
+	mctsMoveCount = mctsSwitch + 3
+)
+
+// simPac is a pac in the simulation
+type simPac struct {
+	id       int
+	mine     bool
+	pos      int
+	kind     int
+	speed    int
+	cooldown int
+	alive    bool
+}
+
+// simState is the state of a simulated game
+type simState struct {
+	pacs []simPac
+	// pellets holds the value we expect to score on each cell, indexed by absolute position
+	pellets []float64
+}
+
+func (state simState) clone() simState {
+	return simState{append([]simPac(nil), state.pacs...), append([]float64(nil), state.pellets...)}
+}
+
+// mctsArm holds the statistics of one of a pac's moves in a node
+type mctsArm struct {
+	visits int
+	total  float64
+}
+
+// mctsNode is a node of the search tree. Since enemy moves are sampled, a node stands for a sequence of our joint moves rather than a
+// game state, and each of our pacs keeps its own statistics for each move it can make there
+type mctsNode struct {
+	visits   int
+	arms     [][mctsMoveCount]mctsArm
+	children map[uint64]*mctsNode
+}
+
+func newMCTSNode(pacCount int) *mctsNode {
+	return &mctsNode{arms: make([][mctsMoveCount]mctsArm, pacCount), children: make(map[uint64]*mctsNode)}
+}
+
+// MCTSAgent is an Agent that decides by searching, rather than following rules like DansLilHeuristicBot
+type MCTSAgent struct {
+	// searchTime is how long each turn's search may last, unless the deadline comes first
+	searchTime time.Duration
+	// maxIterations stops the search after that many iterations, unless it's 0
+	maxIterations int
+	rng           *rand.Rand
+
+	gameMap   GameMap
+	distances *DistanceTable
+	pellets   *PelletBeliefs
+	enemies   *EnemyTracker
+	// steps holds the absolute position one move away in each direction of orthogonalDirections, or -1 for a wall
+	steps [][4]int
+}
+
+// newMCTSAgent creates an agent that searches for searchTime every turn, with random moves drawn from seed
+func newMCTSAgent(seed int64, searchTime time.Duration) *MCTSAgent {
+	return &MCTSAgent{searchTime: searchTime, rng: rand.New(rand.NewSource(seed))}
+}
+
+func (agent *MCTSAgent) init(gameMap GameMap) {
+	agent.gameMap = gameMap
+	agent.distances = newDistanceTable(gameMap)
+	agent.pellets = newPelletBeliefs(gameMap, agent.distances)
+	agent.enemies = newEnemyTracker(gameMap)
+	agent.steps = make([][4]int, len(gameMap.cells))
+	for pos := range gameMap.cells {
+		coord := gameMap.GetCoord(pos)
+		for d, direction := range orthogonalDirections {
+			agent.steps[pos][d] = -1
+			if next := gameMap.Wrap(Coord{coord.x + direction.x, coord.y + direction.y}); gameMap.IsFloor(next) && gameMap.IsFloor(coord) {
+				agent.steps[pos][d] = gameMap.GetAbsolutePosition(next)
+			}
+		}
+	}
+}
+
+func (agent *MCTSAgent) makeCommand(gameData GameData) []Action {
+	return agent.makeTimedCommand(gameData, nil)
+}
+
+// makeTimedCommand searches until deadline or the agent's own search time, whichever comes first
+func (agent *MCTSAgent) makeTimedCommand(gameData GameData, deadline *Deadline) []Action {
+	start := time.Now()
+	if agent.pellets == nil {
+		agent.init(gameData.gameMap)
+	}
+	agent.pellets.observe(gameData)
+	agent.enemies.observe(gameData)
+
+	root, enemyCandidates := agent.rootState(gameData)
+	var mine []int
+	for i, pac := range root.pacs {
+		if pac.mine {
+			mine = append(mine, i)
+		}
+	}
+
+	tree := newMCTSNode(len(mine))
+	iterations := 0
+	for !deadline.expired() && time.Since(start) < agent.searchTime && (agent.maxIterations == 0 || iterations < agent.maxIterations) {
+		state := root.clone()
+		for _, candidates := range enemyCandidates {
+			state.pacs = append(state.pacs, candidates[agent.rng.Intn(len(candidates))])
+		}
+		agent.iterate(tree, state, mine)
+		iterations++
+	}
+	debugf("mcts: %v iterations in %v\n", iterations, time.Since(start).Round(time.Microsecond))
+
+	var actions []Action
+	for i, index := range mine {
+		pac := root.pacs[index]
+		if iterations == 0 {
+			actions = append(actions, fallbackAction(Pac{id: pac.id, pos: agent.gameMap.GetCoord(pac.pos)}, gameData))
+			continue
+		}
+		best, bestVisits := mctsStay, -1
+		for move := mctsStay; move < mctsMoveCount; move++ {
+			if visits := tree.arms[i][move].visits; visits > bestVisits && agent.isLegal(pac, move) {
+				best, bestVisits = move, visits
+			}
+		}
+		actions = append(actions, agent.toAction(pac, best, joinStrings("mcts", bestVisits)))
+	}
+	return actions
+}
+
+// rootState builds the simulation of the current turn from what we see and believe. Enemies out of sight could be on any of several
+// cells, so they're returned separately with every cell each of them could be on, for every iteration to pick one
+func (agent *MCTSAgent) rootState(gameData GameData) (simState, [][]simPac) {
+	gm := agent.gameMap
+	state := simState{pellets: make([]float64, len(gm.cells))}
+	agent.pellets.forEachPellet(func(coord Coord, _ float64) {
+		state.pellets[gm.GetAbsolutePosition(coord)] = agent.pellets.expectedValue(coord)
+	})
+	toSim := func(pac Pac) simPac {
+		kind := 0
+		for i, typeID := range pacTypes {
+			if typeID == pac.typeID {
+				kind = i
+			}
+		}
+		return simPac{pac.id, pac.mine, gm.GetAbsolutePosition(pac.pos), kind, pac.speedTurnsLeft, pac.abilityCooldown, true}
+	}
+	for _, pac := range gameData.visiblePacs {
+		if pac.typeID != deadTypeID {
+			state.pacs = append(state.pacs, toSim(pac))
+		}
+	}
+
+	var candidates [][]simPac
+	byID := make(map[int]int)
+	for _, pac := range agent.enemies.lurkingEnemies(recentSightingTurns) {
+		index, found := byID[pac.id]
+		if !found {
+			index = len(candidates)
+			byID[pac.id] = index
+			candidates = append(candidates, nil)
+		}
+		candidates[index] = append(candidates[index], toSim(pac))
+	}
+	return state, candidates
+}
+
+// iterate plays one simulated game from state, picking our moves down the tree and at random past it, then updates the statistics of
+// the moves it picked with the discounted points they led to
+func (agent *MCTSAgent) iterate(tree *mctsNode, state simState, mine []int) {
+	type visit struct {
+		node  *mctsNode
+		moves []mctsMove
+	}
+	var path []visit
+	rewards := make([]float64, 0, mctsHorizon)
+
+	node := tree
+	moves := make([]mctsMove, len(state.pacs))
+	for depth := 0; depth < mctsHorizon; depth++ {
+		for i, pac := range state.pacs {
+			moves[i] = agent.randomMove(pac)
+		}
+		if node != nil {
+			chosen := agent.selectMoves(node, state, mine)
+			for i, index := range mine {
+				moves[index] = chosen[i]
+			}
+			path = append(path, visit{node, chosen})
+		}
+
+		rewards = append(rewards, agent.simulate(&state, moves))
+
+		if node != nil {
+			if depth+1 >= mctsTreeDepth {
+				node = nil
+			} else if child, found := node.children[mctsKey(path[len(path)-1].moves)]; found {
+				node = child
+			} else {
+				// grow the tree by one node, and play the rest of the game at random
+				node.children[mctsKey(path[len(path)-1].moves)] = newMCTSNode(len(mine))
+				node = nil
+			}
+		}
+	}
+
+	// each node is credited with the points scored from its turn on
+	for depth := len(rewards) - 2; depth >= 0; depth-- {
+		rewards[depth] += mctsDiscount * rewards[depth+1]
+	}
+	for depth, visit := range path {
+		visit.node.visits++
+		for i, move := range visit.moves {
+			arm := &visit.node.arms[i][move]
+			arm.visits++
+			arm.total += rewards[depth]
+		}
+	}
+}
+
+// selectMoves picks a move for each of our pacs with UCB1, trying every legal move once first
+func (agent *MCTSAgent) selectMoves(node *mctsNode, state simState, mine []int) []mctsMove {
+	chosen := make([]mctsMove, len(mine))
+	for i, index := range mine {
+		pac := state.pacs[index]
+		if !pac.alive {
+			continue
+		}
+		best, bestScore := mctsStay, math.Inf(-1)
+		for move := mctsStay; move < mctsMoveCount; move++ {
+			if !agent.isLegal(pac, move) {
+				continue
+			}
+			arm := node.arms[i][move]
+			score := math.Inf(1)
+			if arm.visits > 0 {
+				score = arm.total/float64(arm.visits) + mctsExploration*math.Sqrt(math.Log(float64(node.visits))/float64(arm.visits))
+			}
+			// random tie breaks, so that untried moves aren't always tried in the same order
+			if score > bestScore || (score == bestScore && agent.rng.Intn(2) == 0) {
+				best, bestScore = move, score
+			}
+		}
+		chosen[i] = best
+	}
+	return chosen
+}
+
+// randomMove picks a move at random for pac, mostly moving around since that's how pellets get eaten
+func (agent *MCTSAgent) randomMove(pac simPac) mctsMove {
+	if !pac.alive {
+		return mctsStay
+	}
+	var legal []mctsMove
+	for move := mctsUp; move <= mctsLeft; move++ {
+		if agent.isLegal(pac, move) {
+			legal = append(legal, move)
+		}
+	}
+	if len(legal) == 0 {
+		return mctsStay
+	}
+	return legal[agent.rng.Intn(len(legal))]
+}
+
+func (agent *MCTSAgent) isLegal(pac simPac, move mctsMove) bool {
+	switch {
+	case move == mctsStay:
+		return true
+	case move <= mctsLeft:
+		return agent.steps[pac.pos][move-mctsUp] >= 0
+	case move == mctsSpeed:
+		return pac.cooldown == 0
+	default:
+		return pac.cooldown == 0 && int(move-mctsSwitch) != pac.kind
+	}
+}
+
+// mctsKey packs a joint move into a map key, 4 bits per pac
+func mctsKey(moves []mctsMove) uint64 {
+	var key uint64
+	for _, move := range moves {
+		key = key<<4 | uint64(move)
+	}
+	return key
+}
+
+// simulate plays a turn of state with the given move for each pac, and returns the points we scored minus the points the opponent
+// scored, counting each pac lost as mctsDeathValue points. Like the referee, abilities come first, then one step for every pac and a
+// second one for sped up pacs, each followed by fights and pellet eating. A pac that can't keep going straight on its second step follows
+// the corridor it's in
+func (agent *MCTSAgent) simulate(state *simState, moves []mctsMove) float64 {
+	reward := 0.0
+	directions := make([]int, len(state.pacs))
+	usedAbility := make([]bool, len(state.pacs))
+	for i := range state.pacs {
+		pac := &state.pacs[i]
+		directions[i] = -1
+		if !pac.alive {
+			continue
+		}
+		switch move := moves[i]; {
+		case move >= mctsUp && move <= mctsLeft:
+			directions[i] = int(move - mctsUp)
+		case move == mctsSpeed && pac.cooldown == 0:
+			pac.speed, pac.cooldown, usedAbility[i] = speedDuration, abilityCooldownDuration, true
+		case move >= mctsSwitch && pac.cooldown == 0:
+			pac.kind, pac.cooldown, usedAbility[i] = int(move-mctsSwitch), abilityCooldownDuration, true
+		}
+	}
+
+	previous := make([]int, len(state.pacs))
+	next := make([]int, len(state.pacs))
+	for step := 0; step < 2; step++ {
+		for i, pac := range state.pacs {
+			previous[i], next[i] = pac.pos, pac.pos
+			if !pac.alive || directions[i] < 0 || (step == 1 && pac.speed == 0) {
+				continue
+			}
+			if target := agent.steps[pac.pos][directions[i]]; target >= 0 {
+				next[i] = target
+			} else if step == 1 {
+				// turn into the only other way out, if there's just one
+				back := (directions[i] + 2) % 4
+				exits := 0
+				for d, target := range agent.steps[pac.pos] {
+					if target >= 0 && d != back {
+						exits++
+						next[i], directions[i] = target, d
+					}
+				}
+				if exits != 1 {
+					next[i] = pac.pos
+				}
+			}
+		}
+
+		// pacs of the same player or type block each other, which can block whoever wanted to move into the cell they stayed on
+		for changed := true; changed; {
+			changed = false
+			for i, a := range state.pacs {
+				for j := i + 1; j < len(state.pacs); j++ {
+					b := state.pacs[j]
+					if !a.alive || !b.alive || (a.mine != b.mine && a.kind != b.kind) {
+						continue
+					}
+					if next[i] == next[j] || (next[i] == previous[j] && next[j] == previous[i]) {
+						for _, k := range []int{i, j} {
+							if next[k] != previous[k] {
+								next[k], changed = previous[k], true
+							}
+						}
+					}
+				}
+			}
+		}
+		for i := range state.pacs {
+			state.pacs[i].pos = next[i]
+		}
+
+		for i, a := range state.pacs {
+			for j := i + 1; j < len(state.pacs); j++ {
+				b := state.pacs[j]
+				if !a.alive || !b.alive || a.mine == b.mine || a.kind == b.kind {
+					continue
+				}
+				if a.pos == b.pos || (a.pos == previous[j] && b.pos == previous[i]) {
+					loser := i
+					if a.kind == (b.kind+1)%len(pacTypes) {
+						loser = j
+					}
+					state.pacs[loser].alive = false
+					if state.pacs[loser].mine {
+						reward -= mctsDeathValue
+					} else {
+						reward += mctsDeathValue
+					}
+				}
+			}
+		}
+
+		for _, pac := range state.pacs {
+			if value := state.pellets[pac.pos]; pac.alive && value > 0 {
+				state.pellets[pac.pos] = 0
+				if pac.mine {
+					reward += value
+				} else {
+					reward -= value
+				}
+			}
+		}
+	}
+
+	for i := range state.pacs {
+		pac := &state.pacs[i]
+		if pac.cooldown > 0 {
+			pac.cooldown--
+		}
+		if pac.speed > 0 && !usedAbility[i] {
+			pac.speed--
+		}
+	}
+	return reward
+}
+
+// toAction turns the move picked for pac into a command
+func (agent *MCTSAgent) toAction(pac simPac, move mctsMove, message string) Action {
+	switch {
+	case move == mctsSpeed:
+		return Speed{pac.id, message}
+	case move >= mctsSwitch:
+		return Switch{pac.id, pacTypes[move-mctsSwitch], message}
+	}
+	target := pac.pos
+	if move != mctsStay {
+		// simulating the pac on its own works out where it would end up, speed included
+		state := simState{pacs: []simPac{pac}, pellets: make([]float64, len(agent.gameMap.cells))}
+		agent.simulate(&state, []mctsMove{move})
+		target = state.pacs[0].pos
+	}
+	return Move{pac.id, agent.gameMap.GetCoord(target), message}
+}
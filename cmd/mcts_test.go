@@ -0,0 +1,199 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newTestMCTSAgent returns an agent whose search only stops after a fixed number of iterations, for repeatable tests
+func newTestMCTSAgent(gm GameMap, iterations int) *MCTSAgent {
+	agent := newMCTSAgent(1, time.Hour)
+	agent.maxIterations = iterations
+	agent.init(gm)
+	return agent
+}
+
+func TestMCTSSimulate(t *testing.T) {
+	gm := BuildGameMap(corridorMap)
+	at := func(x int) int { return gm.GetAbsolutePosition(Coord{x, 1}) }
+	// every cell has a pellet, except those the pacs start on
+	pellets := func(pacs []simPac) []float64 {
+		values := make([]float64, len(gm.cells))
+		for x := 1; x <= 5; x++ {
+			values[at(x)] = 1
+		}
+		for _, pac := range pacs {
+			values[pac.pos] = 0
+		}
+		return values
+	}
+
+	tests := []struct {
+		name           string
+		pacs           []simPac
+		moves          []mctsMove
+		expectedPos    []int
+		expectedAlive  []bool
+		expectedReward float64
+	}{
+		{
+			name:           "moving eats a pellet",
+			pacs:           []simPac{{id: 0, mine: true, pos: at(1), alive: true}},
+			moves:          []mctsMove{mctsRight},
+			expectedPos:    []int{at(2)},
+			expectedAlive:  []bool{true},
+			expectedReward: 1,
+		},
+		{
+			name:           "speed eats two pellets",
+			pacs:           []simPac{{id: 0, mine: true, pos: at(1), speed: 2, alive: true}},
+			moves:          []mctsMove{mctsRight},
+			expectedPos:    []int{at(3)},
+			expectedAlive:  []bool{true},
+			expectedReward: 2,
+		},
+		{
+			name:           "walls stop pacs",
+			pacs:           []simPac{{id: 0, mine: true, pos: at(1), alive: true}},
+			moves:          []mctsMove{mctsLeft},
+			expectedPos:    []int{at(1)},
+			expectedAlive:  []bool{true},
+			expectedReward: 0,
+		},
+		{
+			name: "pacs of the same type block each other",
+			pacs: []simPac{
+				{id: 0, mine: true, pos: at(2), alive: true},
+				{id: 0, pos: at(4), alive: true},
+			},
+			moves:          []mctsMove{mctsRight, mctsLeft},
+			expectedPos:    []int{at(2), at(4)},
+			expectedAlive:  []bool{true, true},
+			expectedReward: 0,
+		},
+		{
+			name: "paper eats rock on the same cell",
+			pacs: []simPac{
+				{id: 0, mine: true, pos: at(2), kind: 1, alive: true},
+				{id: 0, pos: at(4), kind: 0, alive: true},
+			},
+			moves:          []mctsMove{mctsRight, mctsLeft},
+			expectedPos:    []int{at(3), at(3)},
+			expectedAlive:  []bool{true, false},
+			expectedReward: mctsDeathValue + 1,
+		},
+		{
+			name: "rock is eaten by paper when they swap cells",
+			pacs: []simPac{
+				{id: 0, mine: true, pos: at(2), kind: 0, alive: true},
+				{id: 0, pos: at(3), kind: 1, alive: true},
+			},
+			moves:          []mctsMove{mctsRight, mctsLeft},
+			expectedPos:    []int{at(3), at(2)},
+			expectedAlive:  []bool{false, true},
+			expectedReward: -mctsDeathValue,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			agent := newTestMCTSAgent(gm, 0)
+			state := simState{pacs: test.pacs, pellets: pellets(test.pacs)}
+			reward := agent.simulate(&state, test.moves)
+
+			var positions []int
+			var alive []bool
+			for _, pac := range state.pacs {
+				positions, alive = append(positions, pac.pos), append(alive, pac.alive)
+			}
+			if !reflect.DeepEqual(test.expectedPos, positions) || !reflect.DeepEqual(test.expectedAlive, alive) {
+				t.Errorf("expected positions %v and alive %v, but got %v and %v", test.expectedPos, test.expectedAlive, positions, alive)
+			}
+			if test.expectedReward != reward {
+				t.Errorf("expected reward %v, but got %v", test.expectedReward, reward)
+			}
+		})
+	}
+}
+
+func TestMCTSSimulateAbilities(t *testing.T) {
+	gm := BuildGameMap(corridorMap)
+	agent := newTestMCTSAgent(gm, 0)
+	state := simState{
+		pacs:    []simPac{{id: 0, mine: true, pos: gm.GetAbsolutePosition(Coord{1, 1}), alive: true}, {id: 1, mine: true, pos: gm.GetAbsolutePosition(Coord{5, 1}), alive: true}},
+		pellets: make([]float64, len(gm.cells)),
+	}
+
+	agent.simulate(&state, []mctsMove{mctsSpeed, mctsSwitch + 2})
+
+	if pac := state.pacs[0]; pac.speed != speedDuration || pac.cooldown != abilityCooldownDuration-1 {
+		t.Errorf("expected speed %v and cooldown %v, but got %+v", speedDuration, abilityCooldownDuration-1, pac)
+	}
+	if pac := state.pacs[1]; pac.kind != 2 || pac.cooldown != abilityCooldownDuration-1 {
+		t.Errorf("expected type 2 and cooldown %v, but got %+v", abilityCooldownDuration-1, pac)
+	}
+	if agent.isLegal(state.pacs[0], mctsSpeed) || agent.isLegal(state.pacs[1], mctsSwitch) {
+		t.Errorf("expected abilities to be illegal during the cooldown")
+	}
+}
+
+func TestMCTSHeadsForPellets(t *testing.T) {
+	gm := BuildGameMap(corridorMap)
+	agent := newTestMCTSAgent(gm, 2000)
+	gameData := GameData{
+		round:          10,
+		gameMap:        gm,
+		scores:         []int{0, 0},
+		visiblePacs:    []Pac{{id: 0, mine: true, pos: Coord{2, 1}, typeID: "ROCK", abilityCooldown: 5}},
+		visiblePellets: []Pellet{{Coord{3, 1}, 1}, {Coord{4, 1}, 1}, {Coord{5, 1}, 1}},
+	}
+
+	actions := agent.makeCommand(gameData)
+
+	if err := validateActions(actions, gameData); err != nil {
+		t.Fatalf("expected valid actions, but got %v", err)
+	}
+	if move, ok := actions[0].(Move); !ok || move.target != (Coord{3, 1}) {
+		t.Errorf("expected a move to (3,1), but got %+v", actions[0])
+	}
+}
+
+func TestMCTSPlaysAGame(t *testing.T) {
+	gm := BuildGameMap(`
+###########
+#         #
+# ### ### #
+#         #
+###########`)
+	pacs := []Pac{
+		{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"},
+		{id: 0, pos: Coord{9, 3}, typeID: "ROCK"},
+	}
+	pellets := initialPellets(gm, pacs, nil)
+	ref := newReferee(gm, pacs, pellets)
+
+	result := ref.play([2]Agent{newTestMCTSAgent(gm, 200), stayPut})
+
+	if result.errors != [2]error{} {
+		t.Fatalf("expected no errors, but got %v", result.errors)
+	}
+	if result.winner != 0 {
+		t.Errorf("expected the MCTS agent to beat a pac that stays put, but got scores %v", result.scores)
+	}
+}
+
+func TestMCTSIgnoresDeadPacs(t *testing.T) {
+	gm := BuildGameMap(corridorMap)
+	agent := newTestMCTSAgent(gm, 50)
+	gameData := GameData{1, gm, []int{0, 0}, []Pac{
+		{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"},
+		{id: 1, mine: true, pos: Coord{3, 1}, typeID: deadTypeID},
+		{id: 0, pos: Coord{2, 1}, typeID: deadTypeID},
+	}, nil}
+
+	actions := agent.makeCommand(gameData)
+	if err := validateActions(actions, gameData); err != nil || len(actions) != 1 {
+		t.Errorf("expected a valid action for the pac still alive only, but got %v: %v", encodeActions(actions), err)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+//-----------------------------------------------------------------------------------
+// Opponent inference: where the opponent's pacs could have scored the points we didn't see them score
+//-----------------------------------------------------------------------------------
+
+// enemySighting is the last time we saw an enemy pac
+type enemySighting struct {
+	pac   Pac
+	round int
+}
+
+// maxSteps returns the farthest the pac can have walked turns after it was sighted: two cells on every turn it has, or could have
+// activated, a speed boost, one cell on every other turn
+func (sighting enemySighting) maxSteps(turns int) int {
+	steps := 0
+	for turn := 1; turn <= turns; turn++ {
+		steps += sighting.stepsOnTurn(turn)
+	}
+	return steps
+}
+
+// stepsOnTurn returns the farthest the pac can walk on the given turn after it was sighted
+func (sighting enemySighting) stepsOnTurn(turn int) int {
+	if turn <= sighting.pac.speedTurnsLeft || turn > sighting.pac.abilityCooldown {
+		return 2
+	}
+	return 1
+}
+
+// opponentEatingCandidates returns the absolute positions of the floor cells out of sight on which the opponent could have eaten pellets
+// on round, given the last sighting of each of its pacs. As long as one of enemyIDs has never been sighted it could be anywhere, so every
+// cell out of sight is a candidate. Pacs last seen dead don't eat anything
+func opponentEatingCandidates(gameMap GameMap, distances *DistanceTable, sightings map[int]enemySighting, enemyIDs []int, round int, inSight map[int]bool) []int {
+	var candidates []int
+	for _, id := range enemyIDs {
+		if _, sighted := sightings[id]; !sighted {
+			for _, pos := range gameMap.FloorPositions() {
+				if !inSight[pos] {
+					candidates = append(candidates, pos)
+				}
+			}
+			return candidates
+		}
+	}
+
+	for _, pos := range gameMap.FloorPositions() {
+		if inSight[pos] {
+			continue
+		}
+		coord := gameMap.GetCoord(pos)
+		for _, sighting := range sightings {
+			if sighting.pac.typeID == deadTypeID {
+				continue
+			}
+			if distances.distance(sighting.pac.pos, coord) <= sighting.maxSteps(round-sighting.round) {
+				candidates = append(candidates, pos)
+				break
+			}
+		}
+	}
+	return candidates
+}
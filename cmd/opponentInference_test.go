@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestEnemySightingMaxSteps(t *testing.T) {
+	tests := []struct {
+		pac      Pac
+		turns    int
+		expected int
+	}{
+		{Pac{abilityCooldown: 5}, 0, 0},
+		{Pac{abilityCooldown: 5}, 3, 3},
+		// the pac can activate speed as soon as its cooldown is over
+		{Pac{abilityCooldown: 2}, 4, 6},
+		{Pac{}, 2, 4},
+		{Pac{speedTurnsLeft: 2, abilityCooldown: 8}, 3, 5},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v after %v turns", tt.pac, tt.turns), func(t *testing.T) {
+			if actual := (enemySighting{tt.pac, 0}).maxSteps(tt.turns); tt.expected != actual {
+				t.Errorf("expected %v steps, but got %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestOpponentEatingCandidates(t *testing.T) {
+	gameMap := BuildGameMap(ringMap)
+	distances := newDistanceTable(gameMap)
+	inSight := map[int]bool{}
+	for _, coord := range gameMap.VisibleCells(Coord{1, 1}) {
+		inSight[gameMap.GetAbsolutePosition(coord)] = true
+	}
+	sightings := map[int]enemySighting{0: {Pac{id: 0, pos: Coord{7, 1}, abilityCooldown: 5}, 3}}
+
+	// two turns without speed only take the enemy two cells away
+	candidates := opponentEatingCandidates(gameMap, distances, sightings, []int{0}, 5, inSight)
+	var coords []Coord
+	for _, pos := range candidates {
+		coords = append(coords, gameMap.GetCoord(pos))
+	}
+	if expected := []Coord{{7, 2}, {7, 3}}; !reflect.DeepEqual(expected, coords) {
+		t.Errorf("expected candidates %v, but got %v", expected, coords)
+	}
+
+	if candidates := opponentEatingCandidates(gameMap, distances, sightings, []int{0, 1}, 5, inSight); len(candidates) != 7 {
+		t.Errorf("expected every cell out of sight to be a candidate while an enemy was never seen, but got %v", candidates)
+	}
+
+	sightings[1] = enemySighting{Pac{id: 1, pos: Coord{1, 3}, typeID: deadTypeID}, 3}
+	if actual := opponentEatingCandidates(gameMap, distances, sightings, []int{0, 1}, 5, inSight); !reflect.DeepEqual(candidates, actual) {
+		t.Errorf("expected an enemy seen dead not to eat anything, but got candidates %v", actual)
+	}
+}
+
+func TestPelletBeliefsOnlyDiscountCellsInReachOfTheOpponent(t *testing.T) {
+	gameMap := BuildGameMap(ringMap)
+	beliefs := newPelletBeliefs(gameMap, newDistanceTable(gameMap))
+	me := Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}
+
+	beliefs.observe(GameData{10, gameMap, []int{0, 0}, []Pac{me, {id: 0, pos: Coord{7, 1}, typeID: "PAPER", abilityCooldown: 5}}, rowPellets(1, 2, 6)})
+	beliefs.observe(GameData{11, gameMap, []int{0, 1}, []Pac{me}, rowPellets(1, 2, 6)})
+
+	// the enemy could only have moved one cell out of sight, so that's where it scored
+	if expected, actual := 0.0, beliefs.probability(Coord{7, 2}); expected != actual {
+		t.Errorf("expected the only cell in reach of the enemy to be empty, but got probability %v", actual)
+	}
+	if expected, actual := 1.0, beliefs.probability(Coord{4, 3}); expected != actual {
+		t.Errorf("expected cells out of the enemy's reach to be untouched, but got probability %v", actual)
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+//-----------------------------------------------------------------------------------
+// Pellet beliefs: where the pellets we can't see are likely to still be
+//-----------------------------------------------------------------------------------
+
+// symmetryOpeningTurns is how many turns into the game the map's symmetry still tells us about pellets out of sight. Past that, a pac could
+// have emptied almost any cell
+const symmetryOpeningTurns = 10
+
+// PelletBeliefs keeps track of the probability that each cell still holds a pellet, from what our pacs see, what enemy pacs are seen
+// doing, and how the opponent's score changes. Super pellets are always visible, so they're tracked separately and with certainty
+type PelletBeliefs struct {
+	gameMap   GameMap
+	distances *DistanceTable
+	// probabilities holds the probability that a regular pellet remains on each cell, indexed by absolute position
+	probabilities []float64
+	// superPellets holds the super pellets left on the map as of the last turn
+	superPellets map[Coord]bool
+	// sightings holds the last sighting of each enemy pac, by id, to work out where it can have been since
+	sightings map[int]enemySighting
+	// enemyIDs holds the ids of the opponent's pacs, which are the same as ours
+	enemyIDs []int
+	// myPositions holds where each of my pacs still alive was as of the last turn, by id
+	myPositions map[int]Coord
+	// starts holds every pac of both players as it stood on the first turn, when the map is symmetric: each of mine and its mirror image
+	starts []Pac
+	// lastRound is the round of the last observed turn, or -1 before the first one
+	lastRound int
+	// lastOpponentScore is the opponent's score as of the last observed turn
+	lastOpponentScore int
+}
+
+// newPelletBeliefs starts out believing there's a pellet on every floor cell
+func newPelletBeliefs(gameMap GameMap, distances *DistanceTable) *PelletBeliefs {
+	beliefs := &PelletBeliefs{
+		gameMap:       gameMap,
+		distances:     distances,
+		probabilities: make([]float64, len(gameMap.cells)),
+		superPellets:  make(map[Coord]bool),
+		sightings:     make(map[int]enemySighting),
+		myPositions:   make(map[int]Coord),
+		lastRound:     -1,
+	}
+	for _, pos := range gameMap.FloorPositions() {
+		beliefs.probabilities[pos] = 1
+	}
+	return beliefs
+}
+
+// observe updates the beliefs with the input of a turn
+func (beliefs *PelletBeliefs) observe(gameData GameData) {
+	gm := beliefs.gameMap
+	inSight := make(map[int]bool)
+	for _, pac := range gameData.visiblePacs {
+		if pac.mine && pac.typeID != deadTypeID {
+			for _, coord := range gm.VisibleCells(pac.pos) {
+				inSight[gm.GetAbsolutePosition(coord)] = true
+			}
+		}
+	}
+
+	// super pellets are always visible, so any that's missing has been eaten
+	superPellets := make(map[Coord]bool)
+	for _, pellet := range gameData.visiblePellets {
+		if pellet.value >= superPelletValue {
+			superPellets[pellet.pos] = true
+		}
+	}
+	eatenByOpponent := 0.0
+	for pos := range beliefs.superPellets {
+		if !superPellets[pos] {
+			beliefs.probabilities[gm.GetAbsolutePosition(pos)] = 0
+			if !beliefs.passedByMyPac(gameData, pos) {
+				eatenByOpponent += superPelletValue
+			}
+		}
+	}
+	beliefs.superPellets = superPellets
+
+	if beliefs.lastRound < 0 {
+		for _, pac := range gameData.visiblePacs {
+			if pac.mine {
+				beliefs.enemyIDs = append(beliefs.enemyIDs, pac.id)
+			}
+		}
+	}
+	if gameData.round < symmetryOpeningTurns {
+		beliefs.mirrorOpening(gameData, inSight)
+	}
+
+	// enemies seen on consecutive turns ate whatever was on their way
+	sightings := make(map[int]enemySighting, len(beliefs.sightings))
+	for id, sighting := range beliefs.sightings {
+		sightings[id] = sighting
+	}
+	for _, pac := range gameData.visiblePacs {
+		if pac.mine {
+			continue
+		}
+		if pac.typeID == deadTypeID {
+			// a dead pac stays where it died without eating anything, but we still know where it is
+			sightings[pac.id] = enemySighting{pac, gameData.round}
+			continue
+		}
+		walked := []Coord{pac.pos}
+		if last, seen := beliefs.sightings[pac.id]; seen && last.round == gameData.round-1 {
+			walked = beliefs.path(last.pac.pos, pac.pos)
+		}
+		eatenByOpponent += beliefs.walkedOver(walked)
+		sightings[pac.id] = enemySighting{pac, gameData.round}
+	}
+
+	// whatever our pacs can see is certain
+	for pos := range inSight {
+		beliefs.probabilities[pos] = 0
+	}
+	for _, pellet := range gameData.visiblePellets {
+		beliefs.probabilities[gm.GetAbsolutePosition(pellet.pos)] = 1
+	}
+
+	// every point the opponent scored that we can't account for was a pellet eaten out of our sight, somewhere its pacs could have been
+	if beliefs.lastRound >= 0 && len(gameData.scores) > 1 {
+		if unexplained := float64(gameData.scores[1]-beliefs.lastOpponentScore) - eatenByOpponent; unexplained > 0 {
+			candidates := opponentEatingCandidates(gm, beliefs.distances, beliefs.sightings, beliefs.enemyIDs, gameData.round, inSight)
+			beliefs.opponentAte(candidates, unexplained)
+		}
+	}
+	beliefs.sightings = sightings
+	for _, pac := range gameData.visiblePacs {
+		if pac.mine && pac.typeID != deadTypeID {
+			beliefs.myPositions[pac.id] = pac.pos
+		} else if pac.mine {
+			delete(beliefs.myPositions, pac.id)
+		}
+	}
+	if len(gameData.scores) > 1 {
+		beliefs.lastOpponentScore = gameData.scores[1]
+	}
+	beliefs.lastRound = gameData.round
+}
+
+// mirrorOpening uses the symmetry of the map over the opening turns. On the first turn, the enemies we can't see stand on the mirror image
+// of our own pacs, and no pellet starts under any pac. Any cell we see empty that no pac can have reached yet started out empty, and so did
+// its mirror image
+func (beliefs *PelletBeliefs) mirrorOpening(gameData GameData, inSight map[int]bool) {
+	gm := beliefs.gameMap
+	if !gm.IsSymmetric() {
+		return
+	}
+	if gameData.round == 0 {
+		for _, twin := range mirroredEnemies(gameData) {
+			beliefs.sightings[twin.id] = enemySighting{twin, gameData.round}
+		}
+		for _, pac := range gameData.visiblePacs {
+			if pac.mine && pac.typeID != deadTypeID {
+				twin := pac
+				twin.mine, twin.pos = false, gm.Mirror(pac.pos)
+				beliefs.starts = append(beliefs.starts, pac, twin)
+			}
+		}
+		for _, pac := range beliefs.starts {
+			beliefs.probabilities[gm.GetAbsolutePosition(pac.pos)] = 0
+		}
+	}
+
+	// reached returns true if a pac can have walked onto coord since the start of the game
+	reached := func(coord Coord) bool {
+		for _, pac := range beliefs.starts {
+			if beliefs.distances.distance(pac.pos, coord) <= (enemySighting{pac, 0}).maxSteps(gameData.round) {
+				return true
+			}
+		}
+		return false
+	}
+	hasPellet := make(map[Coord]bool)
+	for _, pellet := range gameData.visiblePellets {
+		hasPellet[pellet.pos] = true
+	}
+	for pos := range inSight {
+		coord := gm.GetCoord(pos)
+		if mirror := gm.Mirror(coord); !hasPellet[coord] && !reached(coord) && !inSight[gm.GetAbsolutePosition(mirror)] {
+			beliefs.probabilities[gm.GetAbsolutePosition(mirror)] = 0
+		}
+	}
+}
+
+// passedByMyPac returns true if one of my pacs may have walked over pos this turn: pos is on a shortest path from where the pac was last
+// turn to where it is now, which covers both cells a sped up pac walks over. A pac that died this turn may have eaten on its way too
+func (beliefs *PelletBeliefs) passedByMyPac(gameData GameData, pos Coord) bool {
+	for _, pac := range gameData.visiblePacs {
+		if !pac.mine {
+			continue
+		}
+		from, alive := beliefs.myPositions[pac.id]
+		if !alive {
+			if pac.typeID == deadTypeID {
+				continue
+			}
+			from = pac.pos
+		}
+		if beliefs.distances.distance(from, pos)+beliefs.distances.distance(pos, pac.pos) == beliefs.distances.distance(from, pac.pos) {
+			return true
+		}
+	}
+	return false
+}
+
+// path returns the cells along a shortest path from one cell to another, both included
+func (beliefs *PelletBeliefs) path(from, to Coord) []Coord {
+	cells := []Coord{from}
+	for pos := from; pos != to; {
+		next := beliefs.distances.firstStep(pos, to)
+		if next == pos {
+			break
+		}
+		pos = next
+		cells = append(cells, pos)
+	}
+	return cells
+}
+
+// walkedOver records that an enemy pac walked over cells, eating any regular pellet on them, and returns how many points it's believed
+// to have scored
+func (beliefs *PelletBeliefs) walkedOver(cells []Coord) float64 {
+	eaten := 0.0
+	for _, coord := range cells {
+		pos := beliefs.gameMap.GetAbsolutePosition(beliefs.gameMap.Wrap(coord))
+		if !beliefs.superPellets[coord] {
+			eaten += beliefs.probabilities[pos]
+		}
+		beliefs.probabilities[pos] = 0
+	}
+	return eaten
+}
+
+// opponentAte spreads points the opponent scored over the regular pellets that may be left on candidates (absolute positions), in
+// proportion to how likely each of them is to still be there
+func (beliefs *PelletBeliefs) opponentAte(candidates []int, points float64) {
+	total := 0.0
+	for _, pos := range candidates {
+		if !beliefs.superPellets[beliefs.gameMap.GetCoord(pos)] {
+			total += beliefs.probabilities[pos]
+		}
+	}
+	if total <= 0 {
+		return
+	}
+	remaining := 1 - points/total
+	if remaining < 0 {
+		remaining = 0
+	}
+	for _, pos := range candidates {
+		if !beliefs.superPellets[beliefs.gameMap.GetCoord(pos)] {
+			beliefs.probabilities[pos] *= remaining
+		}
+	}
+}
+
+// probability returns how likely it is that coord still holds a pellet
+func (beliefs *PelletBeliefs) probability(coord Coord) float64 {
+	return beliefs.probabilities[beliefs.gameMap.GetAbsolutePosition(beliefs.gameMap.Wrap(coord))]
+}
+
+// value returns what the pellet on coord is worth if it's still there: superPelletValue for a super pellet, 1 for any other floor cell
+func (beliefs *PelletBeliefs) value(coord Coord) int {
+	if beliefs.superPellets[beliefs.gameMap.Wrap(coord)] {
+		return superPelletValue
+	}
+	if beliefs.gameMap.IsFloor(coord) {
+		return 1
+	}
+	return 0
+}
+
+// expectedValue returns the number of points we can expect to score by eating whatever is on coord
+func (beliefs *PelletBeliefs) expectedValue(coord Coord) float64 {
+	return beliefs.probability(coord) * float64(beliefs.value(coord))
+}
+
+// forEachPellet calls f with every cell that may still hold a pellet, in row order
+func (beliefs *PelletBeliefs) forEachPellet(f func(coord Coord, probability float64)) {
+	for pos, probability := range beliefs.probabilities {
+		if probability > 0 {
+			f(beliefs.gameMap.GetCoord(pos), probability)
+		}
+	}
+}
+
+// likelyValues returns the value of the pellet each cell is more likely than not to hold, or 0, indexed by absolute position
+func (beliefs *PelletBeliefs) likelyValues() []int {
+	values := make([]int, len(beliefs.probabilities))
+	for pos, probability := range beliefs.probabilities {
+		if probability > 0.5 {
+			values[pos] = beliefs.value(beliefs.gameMap.GetCoord(pos))
+		}
+	}
+	return values
+}
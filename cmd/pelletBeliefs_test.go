@@ -0,0 +1,101 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+const ringMap = `
+#########
+#       #
+# ##### #
+#       #
+#########`
+
+// rowPellets returns a regular pellet on each cell of row y from x = from to x = to
+func rowPellets(y, from, to int) []Pellet {
+	var pellets []Pellet
+	for x := from; x <= to; x++ {
+		pellets = append(pellets, Pellet{Coord{x, y}, 1})
+	}
+	return pellets
+}
+
+func TestPelletBeliefsSpreadsOpponentScoreOverUnseenCells(t *testing.T) {
+	gameMap := BuildGameMap(ringMap)
+	beliefs := newPelletBeliefs(gameMap, newDistanceTable(gameMap))
+	me := Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}
+
+	// later in the game, when the map's symmetry doesn't tell anything about pellets anymore
+	beliefs.observe(GameData{10, gameMap, []int{0, 0}, []Pac{me}, rowPellets(1, 2, 7)})
+	beliefs.observe(GameData{11, gameMap, []int{0, 2}, []Pac{me}, rowPellets(1, 2, 7)})
+
+	// my pac sees row 1 and column 1, which leaves 7 cells out of sight to have lost 2 pellets between them
+	if expected, actual := 5.0/7, beliefs.probability(Coord{4, 3}); math.Abs(expected-actual) > 1e-9 {
+		t.Errorf("expected an unseen pellet to remain with probability %v, but got %v", expected, actual)
+	}
+	if expected, actual := 1.0, beliefs.probability(Coord{4, 1}); expected != actual {
+		t.Errorf("expected a visible pellet to remain with probability %v, but got %v", expected, actual)
+	}
+	if expected, actual := 0.0, beliefs.probability(Coord{1, 2}); expected != actual {
+		t.Errorf("expected a cell in sight without a pellet to be empty, but got probability %v", actual)
+	}
+}
+
+func TestPelletBeliefsTracksSuperPellets(t *testing.T) {
+	gameMap := BuildGameMap(ringMap)
+	beliefs := newPelletBeliefs(gameMap, newDistanceTable(gameMap))
+	supers := []Pellet{{Coord{4, 1}, superPelletValue}, {Coord{7, 3}, superPelletValue}}
+
+	beliefs.observe(GameData{0, gameMap, []int{0, 0}, []Pac{{id: 0, mine: true, pos: Coord{3, 1}, typeID: "ROCK"}}, supers})
+	if actual := beliefs.expectedValue(Coord{7, 3}); actual != superPelletValue {
+		t.Fatalf("expected a super pellet out of sight at (7,3), but got expected value %v", actual)
+	}
+
+	// my pac ate the first super pellet and the opponent ate the other one, which accounts for all of the opponent's points
+	beliefs.observe(GameData{1, gameMap, []int{10, 10}, []Pac{{id: 0, mine: true, pos: Coord{4, 1}, typeID: "ROCK"}}, nil})
+	if len(beliefs.superPellets) != 0 {
+		t.Errorf("expected both super pellets to be gone, but got %v", beliefs.superPellets)
+	}
+	for _, coord := range []Coord{{4, 1}, {7, 3}} {
+		if actual := beliefs.expectedValue(coord); actual != 0 {
+			t.Errorf("expected %v to be empty, but got expected value %v", coord, actual)
+		}
+	}
+	if expected, actual := 1.0, beliefs.probability(Coord{4, 3}); expected != actual {
+		t.Errorf("expected unseen pellets to be untouched, but got probability %v", actual)
+	}
+}
+
+func TestPelletBeliefsCreditsSuperPelletsMyPacsSpedOver(t *testing.T) {
+	gameMap := BuildGameMap(ringMap)
+	beliefs := newPelletBeliefs(gameMap, newDistanceTable(gameMap))
+	me := Pac{id: 0, mine: true, pos: Coord{2, 1}, typeID: "ROCK", speedTurnsLeft: 3}
+
+	beliefs.observe(GameData{10, gameMap, []int{0, 0}, []Pac{me}, append([]Pellet{{Coord{3, 1}, superPelletValue}}, rowPellets(1, 4, 7)...)})
+	// my pac sped over the super pellet, so the opponent's point was a pellet eaten somewhere out of sight
+	me.pos, me.speedTurnsLeft = Coord{4, 1}, 2
+	beliefs.observe(GameData{11, gameMap, []int{11, 1}, []Pac{me}, rowPellets(1, 5, 7)})
+
+	if expected, actual := 8.0/9, beliefs.probability(Coord{4, 3}); math.Abs(expected-actual) > 1e-9 {
+		t.Errorf("expected an unseen pellet to remain with probability %v, but got %v", expected, actual)
+	}
+}
+
+func TestPelletBeliefsClearsCellsEnemiesWalkedOver(t *testing.T) {
+	gameMap := BuildGameMap(ringMap)
+	beliefs := newPelletBeliefs(gameMap, newDistanceTable(gameMap))
+	enemy := Pac{id: 0, pos: Coord{7, 1}, typeID: "PAPER"}
+
+	beliefs.observe(GameData{0, gameMap, []int{0, 0}, []Pac{{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}, enemy}, rowPellets(1, 2, 6)})
+	enemy.pos = Coord{7, 3}
+	beliefs.observe(GameData{1, gameMap, []int{0, 2}, []Pac{{id: 0, mine: true, pos: Coord{1, 3}, typeID: "ROCK"}, enemy}, rowPellets(3, 2, 6)})
+
+	if expected, actual := 0.0, beliefs.probability(Coord{7, 2}); expected != actual {
+		t.Errorf("expected the enemy to have eaten the pellet it walked over out of sight, but got probability %v", actual)
+	}
+	// the enemy's 2 points are accounted for by (7,2) and (7,3)
+	if expected, actual := 1.0, beliefs.probability(Coord{4, 1}); expected != actual {
+		t.Errorf("expected a pellet seen last turn to remain, but got probability %v", actual)
+	}
+}
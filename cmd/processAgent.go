@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+//-----------------------------------------------------------------------------------
+// ProcessAgent: plays a compiled bot over the real stdin/stdout protocol
+//-----------------------------------------------------------------------------------
+
+const (
+	// firstTurnTimeout is how long the arena waits for the response to the first turn, initialization included
+	firstTurnTimeout = 1000 * time.Millisecond
+	// turnTimeout is how long the arena waits for the response to every other turn
+	turnTimeout = 50 * time.Millisecond
+	// closeTimeout is how long a bot gets to exit on its own once the game is over, e.g. to finish writing its logs
+	closeTimeout = 500 * time.Millisecond
+)
+
+// faultyAgent is implemented by agents that can fail on their own, like a bot process that crashes or times out
+type faultyAgent interface {
+	// fault returns the reason the agent can't play anymore, or nil
+	fault() error
+	// forfeit is told why the referee disqualified the agent, and returns that reason with any context the agent can add to it
+	forfeit(err error) error
+}
+
+// BotError explains why a bot process lost the game, along with everything it wrote to stderr
+type BotError struct {
+	turn   int
+	err    error
+	stderr string
+}
+
+func (e *BotError) Error() string {
+	return fmt.Sprintf("turn %v: %v\n--- stderr ---\n%v", e.turn, e.err, e.stderr)
+}
+
+// ProcessAgent is an Agent backed by a bot executable, which is fed the same input main reads and must answer with a command line in time
+type ProcessAgent struct {
+	cmd *exec.Cmd
+	// firstTurnTimeout and turnTimeout default to the arena's limits, but can be loosened for slow test machines
+	firstTurnTimeout, turnTimeout time.Duration
+
+	stdin io.WriteCloser
+	// initMap is the map to send the init input about, along with the first turn's input: the arena's first turn clock covers both
+	initMap *GameMap
+	lines   chan string
+	stderr  *syncBuffer
+	// stderrDone is closed once the process's stderr has been read to the end
+	stderrDone chan struct{}
+	// stderrTaken is how much of stderr has already been returned by takeDebug
+	stderrTaken int
+	turn        int
+	err         error
+	exited      bool
+}
+
+// syncBuffer is a bytes.Buffer that can be written by the process's stderr copier while the agent reads it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// newProcessAgent creates an agent that runs the executable at path with args. The process is started by init
+func newProcessAgent(path string, args ...string) *ProcessAgent {
+	return &ProcessAgent{
+		cmd:              exec.Command(path, args...),
+		firstTurnTimeout: firstTurnTimeout,
+		turnTimeout:      turnTimeout,
+		stderr:           &syncBuffer{},
+	}
+}
+
+func (agent *ProcessAgent) init(gameMap GameMap) {
+	// pipes rather than a plain io.Writer for stderr, because Wait would otherwise block on any child process the bot left behind
+	stdout, err := agent.cmd.StdoutPipe()
+	var stderr io.ReadCloser
+	if err == nil {
+		stderr, err = agent.cmd.StderrPipe()
+	}
+	if err == nil {
+		agent.stdin, err = agent.cmd.StdinPipe()
+	}
+	if err == nil {
+		err = agent.cmd.Start()
+	}
+	if err != nil {
+		agent.fail(fmt.Errorf("couldn't start bot: %v", err))
+		return
+	}
+
+	agent.lines = make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			agent.lines <- scanner.Text()
+		}
+		close(agent.lines)
+	}()
+	agent.stderrDone = make(chan struct{})
+	go func() {
+		io.Copy(agent.stderr, stderr)
+		close(agent.stderrDone)
+	}()
+
+	agent.initMap = &gameMap
+}
+
+func (agent *ProcessAgent) makeCommand(gameData GameData) []Action {
+	if agent.err != nil {
+		return nil
+	}
+	agent.turn = gameData.round
+	timeout := agent.turnTimeout
+	if agent.initMap != nil {
+		timeout = agent.firstTurnTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var err error
+	if agent.initMap != nil {
+		err = writeInitInput(agent.stdin, *agent.initMap)
+		agent.initMap = nil
+	}
+	if err == nil {
+		err = writeTurnInput(agent.stdin, gameData)
+	}
+	if err != nil {
+		agent.crashed()
+		return nil
+	}
+
+	select {
+	case line, ok := <-agent.lines:
+		if !ok {
+			agent.crashed()
+			return nil
+		}
+		actions, err := parseActions(line)
+		if err != nil {
+			agent.fail(fmt.Errorf("malformed output: %v", err))
+		}
+		return actions
+	case <-timer.C:
+		agent.fail(fmt.Errorf("timed out after %v", timeout))
+		return nil
+	}
+}
+
+func (agent *ProcessAgent) takeDebug() string {
+	stderr := agent.stderr.String()
+	debugText := stderr[agent.stderrTaken:]
+	agent.stderrTaken = len(stderr)
+	return debugText
+}
+
+func (agent *ProcessAgent) fault() error {
+	return agent.err
+}
+
+func (agent *ProcessAgent) forfeit(err error) error {
+	if agent.err == nil {
+		agent.fail(err)
+	}
+	return agent.err
+}
+
+// crashed records that the process stopped talking to us, with its exit status when it has one. A process that closed its pipes but
+// kept running is killed after closeTimeout
+func (agent *ProcessAgent) crashed() {
+	// read everything the process wrote before it died, since Wait closes the pipes
+	agent.drain(closeTimeout)
+	agent.cmd.Process.Kill()
+	err := agent.wait()
+	if err == nil {
+		err = fmt.Errorf("bot exited")
+	}
+	agent.fail(fmt.Errorf("crashed: %v", err))
+}
+
+// drain reads the process's output until it closes both stdout and stderr, which it does when it exits, or until timeout runs out
+func (agent *ProcessAgent) drain(timeout time.Duration) {
+	if agent.lines == nil {
+		return
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for lines := agent.lines; lines != nil; {
+		select {
+		case _, open := <-lines:
+			if !open {
+				lines = nil
+			}
+		case <-timer.C:
+			return
+		}
+	}
+	select {
+	case <-agent.stderrDone:
+	case <-timer.C:
+	}
+}
+
+func (agent *ProcessAgent) fail(err error) {
+	agent.err = &BotError{agent.turn, err, agent.stderr.String()}
+}
+
+func (agent *ProcessAgent) wait() error {
+	if agent.exited || agent.cmd.Process == nil {
+		return nil
+	}
+	agent.exited = true
+	return agent.cmd.Wait()
+}
+
+// close ends the bot's input so that it can exit on its own, and kills it if it's still running after closeTimeout. The referee calls it
+// once the game is over
+func (agent *ProcessAgent) close() {
+	if agent.cmd.Process == nil || agent.exited {
+		return
+	}
+	agent.stdin.Close()
+	agent.drain(closeTimeout)
+	agent.cmd.Process.Kill()
+	agent.wait()
+	if agent.lines != nil {
+		// Wait closed the pipes, so this only discards whatever was left unread
+		for range agent.lines {
+		}
+	}
+}
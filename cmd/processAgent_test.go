@@ -0,0 +1,136 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newProcessTestReferee() *Referee {
+	gm := BuildGameMap(corridorMap)
+	pacs := []Pac{{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}, {id: 0, pos: Coord{5, 1}, typeID: "ROCK"}}
+	return newReferee(gm, pacs, initialPellets(gm, pacs, nil))
+}
+
+func TestProcessAgentForfeits(t *testing.T) {
+	tests := []struct {
+		description string
+		script      string
+		expected    string
+	}{
+		{"timeout", "cat > /dev/null", "timed out"},
+		{"crash", "echo 'oops' >&2; exit 3", "crashed"},
+		{"output closed while still running", "exec >&-; exec sleep 10", "crashed"},
+		{"malformed output", "while read line; do echo HELLO; done", "malformed output"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			agent := newProcessAgent("sh", "-c", tt.script)
+			agent.firstTurnTimeout = 100 * time.Millisecond
+
+			result := newProcessTestReferee().play([2]Agent{agent, stayPut})
+
+			if result.winner != 1 || result.errors[0] == nil {
+				t.Fatalf("expected the bot to lose by forfeit, but got %+v", result)
+			}
+			if err := result.errors[0].Error(); !strings.Contains(err, tt.expected) {
+				t.Errorf("expected the error to mention %q, but got %q", tt.expected, err)
+			}
+			if _, ok := result.errors[0].(*BotError); !ok {
+				t.Errorf("expected a *BotError, but got %T", result.errors[0])
+			}
+			if agent.cmd.ProcessState == nil {
+				t.Errorf("expected the bot to be done with once the game is over")
+			}
+		})
+	}
+}
+
+func TestProcessAgentCapturesStderr(t *testing.T) {
+	agent := newProcessAgent("sh", "-c", "echo 'about to crash' >&2; exit 1")
+
+	result := newProcessTestReferee().play([2]Agent{agent, stayPut})
+
+	if result.errors[0] == nil || !strings.Contains(result.errors[0].Error(), "about to crash") {
+		t.Errorf("expected the error to contain the bot's stderr, but got %v", result.errors[0])
+	}
+}
+
+func TestProcessAgentFirstTurnIncludesInit(t *testing.T) {
+	// the bot takes its time over the init input, which only gets sent once the other player has played its first turn
+	agent := newProcessAgent("sh", "-c", "read size; sleep 0.3; while read line; do echo MOVE 0 5 1; done")
+	agent.firstTurnTimeout = 200 * time.Millisecond
+	slow := agentFunc(func(GameData) []Action {
+		time.Sleep(300 * time.Millisecond)
+		return nil
+	})
+
+	result := newProcessTestReferee().play([2]Agent{slow, agent})
+
+	if result.errors[1] == nil || !strings.Contains(result.errors[1].Error(), "timed out") {
+		t.Errorf("expected the bot to time out on its first turn, but got %v", result.errors[1])
+	}
+}
+
+func TestProcessAgentPlaysCompiledBot(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping bot compilation in short mode")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+	dir, err := ioutil.TempDir("", "pacman")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	botPath := filepath.Join(dir, "bot")
+	if output, err := exec.Command(goTool, "build", "-o", botPath, ".").CombinedOutput(); err != nil {
+		t.Fatalf("couldn't build the bot: %v\n%s", err, output)
+	}
+
+	replayPath := filepath.Join(dir, "replay.jsonl")
+	var agents [2]Agent
+	for i := range agents {
+		agent := newProcessAgent(botPath)
+		if i == 0 {
+			agent = newProcessAgent(botPath, "-replay", replayPath)
+		}
+		// the arena's 50ms are tight for a loaded test machine, and we only care about the protocol here
+		agent.firstTurnTimeout, agent.turnTimeout = 5*time.Second, time.Second
+		agents[i] = agent
+	}
+
+	result := newProcessTestReferee().play(agents)
+
+	if result.errors != [2]error{} {
+		t.Fatalf("expected no errors, but got %v", result.errors)
+	}
+	if len(result.turns) == 0 {
+		t.Fatalf("expected the bots to play at least one turn")
+	}
+	// the referee let the bot exit, so it's done writing its replay
+	file, err := os.Open(replayPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	replay, err := readReplay(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := len(result.turns), len(replay.turns); expected != actual {
+		t.Fatalf("expected the bot to record %v turns, but got %v", expected, actual)
+	}
+	for turn, replayTurn := range replay.turns {
+		if expected, actual := result.turns[turn].commands[0], replayTurn.players[0].command; expected != actual {
+			t.Errorf("expected the bot to record command %q on turn %v, but got %q", expected, turn, actual)
+		}
+	}
+}
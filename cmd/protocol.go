@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//-----------------------------------------------------------------------------------
+// CodinGame input protocol
+//-----------------------------------------------------------------------------------
+
+// writeInitInput writes the initialization block that main reads before the first turn: the map size, then one line per grid row
+func writeInitInput(w io.Writer, gameMap GameMap) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, gameMap.width, gameMap.height)
+	for _, row := range gameMap.rows() {
+		fmt.Fprintln(bw, row)
+	}
+	return bw.Flush()
+}
+
+// writeTurnInput writes the block that main reads at the start of each turn: scores, visible pacs and visible pellets
+func writeTurnInput(w io.Writer, gameData GameData) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, gameData.scores[0], gameData.scores[1])
+	fmt.Fprintln(bw, len(gameData.visiblePacs))
+	for _, pac := range gameData.visiblePacs {
+		player := 0
+		if pac.mine {
+			player = 1
+		}
+		fmt.Fprintln(bw, pac.id, player, pac.pos.x, pac.pos.y, pac.typeID, pac.speedTurnsLeft, pac.abilityCooldown)
+	}
+	fmt.Fprintln(bw, len(gameData.visiblePellets))
+	for _, pellet := range gameData.visiblePellets {
+		fmt.Fprintln(bw, pellet.pos.x, pellet.pos.y, pellet.value)
+	}
+	return bw.Flush()
+}
+
+// ProtocolError reports a line of game input that couldn't be parsed
+type ProtocolError struct {
+	// line is the number of the offending line, starting at 1
+	line int
+	text string
+	err  error
+}
+
+func (e *ProtocolError) Error() string {
+	return fmt.Sprintf("line %v: %v: %q", e.line, e.err, e.text)
+}
+
+func (e *ProtocolError) Unwrap() error {
+	return e.err
+}
+
+// ProtocolReader parses the game input main receives on stdin: the initialization block, then one block per turn
+type ProtocolReader struct {
+	scanner *bufio.Scanner
+	// line is the number of lines read so far
+	line    int
+	gameMap GameMap
+	round   int
+	// raw contains the lines of the last block read
+	raw []string
+	// blockStart is when the first line of the last block was read, which starts the clock on the turn
+	blockStart time.Time
+}
+
+// newProtocolReader creates a reader parsing the game input from r
+func newProtocolReader(r io.Reader) *ProtocolReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 1000000), 1000000)
+	return &ProtocolReader{scanner: scanner}
+}
+
+// rawLines returns the lines of the last block read, e.g. to copy them to a game log
+func (reader *ProtocolReader) rawLines() []string {
+	return reader.raw
+}
+
+// nextLine reads the next line of input. It returns io.EOF if the input ended at the start of a block, and a ProtocolError wrapping
+// io.ErrUnexpectedEOF if the input ended in the middle of one
+func (reader *ProtocolReader) nextLine() (string, error) {
+	if !reader.scanner.Scan() {
+		err := reader.scanner.Err()
+		if err == nil && len(reader.raw) == 0 {
+			return "", io.EOF
+		}
+		if err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+		return "", &ProtocolError{reader.line + 1, "", err}
+	}
+	if len(reader.raw) == 0 {
+		reader.blockStart = time.Now()
+	}
+	reader.line++
+	text := reader.scanner.Text()
+	reader.raw = append(reader.raw, text)
+	return text, nil
+}
+
+// nextInts reads the next line of input, which must hold exactly len(targets) integers
+func (reader *ProtocolReader) nextInts(targets ...*int) error {
+	text, err := reader.nextLine()
+	if err != nil {
+		return err
+	}
+	fields := strings.Fields(text)
+	if len(fields) != len(targets) {
+		return &ProtocolError{reader.line, text, fmt.Errorf("expected %v values, but got %v", len(targets), len(fields))}
+	}
+	for i, field := range fields {
+		if *targets[i], err = strconv.Atoi(field); err != nil {
+			return &ProtocolError{reader.line, text, fmt.Errorf("invalid number %q", field)}
+		}
+	}
+	return nil
+}
+
+// nextCount reads a line holding a single non-negative number of entries
+func (reader *ProtocolReader) nextCount() (int, error) {
+	var count int
+	if err := reader.nextInts(&count); err != nil {
+		return 0, err
+	}
+	if count < 0 {
+		return 0, &ProtocolError{reader.line, reader.raw[len(reader.raw)-1], fmt.Errorf("negative count")}
+	}
+	return count, nil
+}
+
+// checkCoord returns a ProtocolError for the last line read if coord is outside the map
+func (reader *ProtocolReader) checkCoord(coord Coord) error {
+	if coord.x < 0 || coord.x >= reader.gameMap.width || coord.y < 0 || coord.y >= reader.gameMap.height {
+		return &ProtocolError{reader.line, reader.raw[len(reader.raw)-1], fmt.Errorf("coordinates %v,%v outside the map", coord.x, coord.y)}
+	}
+	return nil
+}
+
+// readInit reads the initialization block: the map size, then one line per grid row
+func (reader *ProtocolReader) readInit() (GameMap, error) {
+	reader.raw = nil
+	var width, height int
+	if err := reader.nextInts(&width, &height); err != nil {
+		return GameMap{}, err
+	}
+	if width <= 0 || height <= 0 {
+		return GameMap{}, &ProtocolError{reader.line, reader.raw[0], fmt.Errorf("invalid map size")}
+	}
+	var cells []Cell
+	for y := 0; y < height; y++ {
+		// one line of the grid: space " " is floor, pound "#" is wall
+		row, err := reader.nextLine()
+		if err != nil {
+			return GameMap{}, err
+		}
+		if len(row) != width || strings.Trim(row, " #") != "" {
+			return GameMap{}, &ProtocolError{reader.line, row, fmt.Errorf("expected %v floor or wall cells", width)}
+		}
+		for _, cellValue := range row {
+			cells = append(cells, Cell{cellValue})
+		}
+	}
+	gm := newGameMap(width, height, cells)
+	reader.gameMap = gm
+	return gm, nil
+}
+
+// readTurn reads the input of a turn: scores, visible pacs and visible pellets. It returns io.EOF once the game is over
+func (reader *ProtocolReader) readTurn() (GameData, error) {
+	reader.raw = nil
+	gameData := GameData{round: reader.round, gameMap: reader.gameMap, scores: make([]int, 2)}
+	if err := reader.nextInts(&gameData.scores[0], &gameData.scores[1]); err != nil {
+		return GameData{}, err
+	}
+
+	// visiblePacCount: all your pacs and enemy pacs in sight
+	visiblePacCount, err := reader.nextCount()
+	if err != nil {
+		return GameData{}, err
+	}
+	for i := 0; i < visiblePacCount; i++ {
+		text, err := reader.nextLine()
+		if err != nil {
+			return GameData{}, err
+		}
+		// pacId, mine (1 or 0), x, y, typeId, speedTurnsLeft, abilityCooldown
+		fields := strings.Fields(text)
+		if len(fields) != 7 {
+			return GameData{}, &ProtocolError{reader.line, text, fmt.Errorf("expected 7 values, but got %v", len(fields))}
+		}
+		var pac Pac
+		var player int
+		pac.typeID = PacType(fields[4])
+		if !isPacType(pac.typeID) && pac.typeID != deadTypeID {
+			return GameData{}, &ProtocolError{reader.line, text, fmt.Errorf("unknown pac type %q", fields[4])}
+		}
+		targets := []*int{&pac.id, &player, &pac.pos.x, &pac.pos.y, nil, &pac.speedTurnsLeft, &pac.abilityCooldown}
+		for j, field := range fields {
+			if targets[j] == nil {
+				continue
+			}
+			if *targets[j], err = strconv.Atoi(field); err != nil {
+				return GameData{}, &ProtocolError{reader.line, text, fmt.Errorf("invalid number %q", field)}
+			}
+		}
+		if player != 0 && player != 1 {
+			return GameData{}, &ProtocolError{reader.line, text, fmt.Errorf("invalid owner %v", player)}
+		}
+		if err := reader.checkCoord(pac.pos); err != nil {
+			return GameData{}, err
+		}
+		pac.mine = player == 1
+		gameData.visiblePacs = append(gameData.visiblePacs, pac)
+	}
+
+	// visiblePelletCount: all pellets in sight
+	visiblePelletCount, err := reader.nextCount()
+	if err != nil {
+		return GameData{}, err
+	}
+	for i := 0; i < visiblePelletCount; i++ {
+		// value: amount of points this pellet is worth
+		var pellet Pellet
+		if err := reader.nextInts(&pellet.pos.x, &pellet.pos.y, &pellet.value); err != nil {
+			return GameData{}, err
+		}
+		if err := reader.checkCoord(pellet.pos); err != nil {
+			return GameData{}, err
+		}
+		gameData.visiblePellets = append(gameData.visiblePellets, pellet)
+	}
+
+	reader.round++
+	return gameData, nil
+}
+
+// readCommand reads a command line, as found after each turn's input in a game log
+func (reader *ProtocolReader) readCommand() (string, error) {
+	reader.raw = nil
+	return reader.nextLine()
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProtocolReaderReadsWhatIsWritten(t *testing.T) {
+	gameMap := BuildGameMap(corridorMap)
+	turns := []GameData{
+		{0, gameMap, []int{0, 0}, []Pac{{0, true, Coord{1, 1}, "ROCK", 0, 0}, {0, false, Coord{5, 1}, "PAPER", 0, 0}}, []Pellet{{Coord{2, 1}, 1}, {Coord{3, 1}, 10}}},
+		{1, gameMap, []int{1, 3}, []Pac{{0, true, Coord{2, 1}, "ROCK", 4, 9}, {1, true, Coord{4, 1}, deadTypeID, 0, 0}}, nil},
+	}
+	var input bytes.Buffer
+	writeInitInput(&input, gameMap)
+	for _, gameData := range turns {
+		writeTurnInput(&input, gameData)
+	}
+
+	reader := newProtocolReader(&input)
+	actualMap, err := reader.readInit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gameMap, actualMap) {
+		t.Errorf("expected map %v, but got %v", gameMap, actualMap)
+	}
+	if expected, actual := []string{"7 3", "#######", "#     #", "#######"}, reader.rawLines(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected raw lines %q, but got %q", expected, actual)
+	}
+	for _, expected := range turns {
+		actual, err := reader.readTurn()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("expected %+v, but got %+v", expected, actual)
+		}
+	}
+	if expected, actual := []string{"1 3", "2", "0 1 2 1 ROCK 4 9", "1 1 4 1 DEAD 0 0", "0"}, reader.rawLines(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected raw lines %q, but got %q", expected, actual)
+	}
+	if _, err := reader.readTurn(); err != io.EOF {
+		t.Errorf("expected io.EOF at the end of the game, but got %v", err)
+	}
+}
+
+func TestProtocolReaderErrors(t *testing.T) {
+	const header = "7 3\n#######\n#     #\n#######\n"
+	tests := []struct {
+		description string
+		input       string
+		line        int
+		truncated   bool
+	}{
+		{"empty map size", "\n", 1, false},
+		{"invalid map size", "7 x\n", 1, false},
+		{"missing row", "7 3\n#######\n", 3, true},
+		{"row too short", "7 3\n#######\n#   #\n#######\n", 3, false},
+		{"unknown cell", "7 3\n#######\n#  x  #\n#######\n", 3, false},
+		{"invalid score", header + "0\n", 5, false},
+		{"negative count", header + "0 0\n-1\n", 6, false},
+		{"missing pac", header + "0 0\n2\n0 1 1 1 ROCK 0 0\n", 8, true},
+		{"pac with too few values", header + "0 0\n1\n0 1 1 1 ROCK 0\n", 7, false},
+		{"invalid pac owner", header + "0 0\n1\n0 2 1 1 ROCK 0 0\n", 7, false},
+		{"unknown pac type", header + "0 0\n1\n0 1 1 1 LIZARD 0 0\n", 7, false},
+		{"invalid pellet", header + "0 0\n0\n1\n1 1 z\n", 8, false},
+		{"pac outside the map", header + "0 0\n1\n0 1 7 1 ROCK 0 0\n", 7, false},
+		{"pellet outside the map", header + "0 0\n0\n1\n1 -1 1\n", 8, false},
+		{"missing pellet count", header + "0 0\n0\n", 7, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			reader := newProtocolReader(strings.NewReader(tt.input))
+			_, err := reader.readInit()
+			if err == nil {
+				_, err = reader.readTurn()
+			}
+			var protocolErr *ProtocolError
+			if !errors.As(err, &protocolErr) {
+				t.Fatalf("expected a *ProtocolError, but got %v", err)
+			}
+			if protocolErr.line != tt.line {
+				t.Errorf("expected an error on line %v, but got %v", tt.line, err)
+			}
+			if truncated := errors.Is(err, io.ErrUnexpectedEOF); truncated != tt.truncated {
+				t.Errorf("expected truncated to be %v, but got %v", tt.truncated, err)
+			}
+		})
+	}
+}
+
+func TestPlayGameFromCapturedInput(t *testing.T) {
+	input := "7 3\n#######\n#     #\n#######\n" +
+		"0 0\n2\n0 1 1 1 ROCK 0 0\n0 0 5 1 ROCK 0 0\n3\n2 1 1\n3 1 1\n4 1 1\n" +
+		"1 1\n2\n0 1 2 1 ROCK 0 0\n0 0 4 1 ROCK 0 0\n1\n3 1 1\n"
+	defer func(previous io.Writer) { debugOutput = previous }(debugOutput)
+	debugOutput = &bytes.Buffer{}
+
+	var out, gameLog bytes.Buffer
+	if err := playGame(&DansLilHeuristicBot{}, strings.NewReader(input), &out, &gameLog, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	commands := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if expected, actual := 2, len(commands); expected != actual {
+		t.Fatalf("expected %v commands, but got %q", expected, commands)
+	}
+	turns, loggedCommands, err := readGameLog(&gameLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(turns) != 2 || !reflect.DeepEqual(commands, loggedCommands) {
+		t.Errorf("expected the game log to hold 2 turns and commands %q, but got %v turns and %q", commands, len(turns), loggedCommands)
+	}
+
+	if err := playGame(&DansLilHeuristicBot{}, strings.NewReader("7 3\n#######\n"), &out, nil, nil); err == nil {
+		t.Errorf("expected an error for truncated input")
+	}
+}
@@ -0,0 +1,517 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+//-----------------------------------------------------------------------------------
+// Referee: a local implementation of the Spring Challenge 2020 rules, so bots can play each other offline
+//-----------------------------------------------------------------------------------
+
+const (
+	// maxTurns is the number of turns after which the game is stopped and scores are compared
+	maxTurns = 200
+	// speedDuration is the number of turns a pac moves two cells per turn after activating SPEED
+	speedDuration = 5
+	// abilityCooldownDuration is the number of turns a pac must wait between two abilities
+	abilityCooldownDuration = 10
+	// superPelletValue is the value of a super pellet, the only pellets that are visible from anywhere on the map
+	superPelletValue = 10
+)
+
+// initializer is implemented by agents that need to inspect the map before the first turn
+type initializer interface {
+	init(GameMap)
+}
+
+// closer is implemented by agents that hold on to resources once the game is over, like bot processes
+type closer interface {
+	close()
+}
+
+// debugReporter is implemented by agents that don't write their debug output through debug and debugf, like bot processes
+type debugReporter interface {
+	// takeDebug returns the debug output written since the last call
+	takeDebug() string
+}
+
+// RefereeTurn is a snapshot of the full (unfogged) state of the game at the end of a turn
+type RefereeTurn struct {
+	turn int
+	// pacs contains every pac, including dead ones (whose typeID is DEAD). mine is true for player 0's pacs
+	pacs []Pac
+	// pellets contains every pellet left on the map, sorted by position
+	pellets []Pellet
+	// scores contains each player's score, indexed by player
+	scores [2]int
+	// commands contains the raw command each player issued this turn, indexed by player
+	commands [2]string
+}
+
+// MatchResult is the outcome of a game played by the referee
+type MatchResult struct {
+	// scores contains each player's final score, indexed by player
+	scores [2]int
+	// winner is the index of the winning player, or -1 for a draw
+	winner int
+	// errors contains the reason a player forfeited (invalid command, panic...), or nil, indexed by player
+	errors [2]error
+	// turns contains the state of the game at the end of every turn
+	turns []RefereeTurn
+	// replayErr is the reason the replay couldn't be recorded to the end, if the referee had a recorder
+	replayErr error
+}
+
+// Referee holds the true state of a game and applies the rules to it
+type Referee struct {
+	gameMap GameMap
+	// pacs contains every pac of both players. mine is true for player 0's pacs, and dead pacs have a DEAD typeID
+	pacs    []Pac
+	pellets map[Coord]int
+	scores  [2]int
+	turn    int
+	// recorder records every turn of the game when set, along with the debug output of both agents
+	recorder *ReplayRecorder
+}
+
+// pacOrder is what a player asked a single pac to do this turn
+type pacOrder struct {
+	// ability is SPEED or SWITCH, or empty for a move (or no order at all)
+	ability string
+	// typeID is the type to switch to when ability is SWITCH
+	typeID PacType
+	target Coord
+	moving bool
+}
+
+// newReferee creates a referee for a game on gameMap. pacs belonging to player 0 must have mine set to true
+func newReferee(gameMap GameMap, pacs []Pac, pellets []Pellet) *Referee {
+	ref := &Referee{gameMap: gameMap, pellets: make(map[Coord]int, len(pellets))}
+	ref.pacs = append(ref.pacs, pacs...)
+	for _, pellet := range pellets {
+		ref.pellets[pellet.pos] = pellet.value
+	}
+	return ref
+}
+
+// initialPellets places a pellet of value 1 on every floor cell that isn't occupied by a pac, and a super pellet on each of superPellets
+func initialPellets(gameMap GameMap, pacs []Pac, superPellets []Coord) []Pellet {
+	values := make(map[Coord]int)
+	for _, super := range superPellets {
+		values[super] = superPelletValue
+	}
+	occupied := make(map[Coord]bool, len(pacs))
+	for _, pac := range pacs {
+		occupied[pac.pos] = true
+	}
+
+	var pellets []Pellet
+	for pos, cell := range gameMap.cells {
+		coord := gameMap.GetCoord(pos)
+		if cell.value != ' ' || occupied[coord] {
+			continue
+		}
+		value, isSuper := values[coord]
+		if !isSuper {
+			value = 1
+		}
+		pellets = append(pellets, Pellet{coord, value})
+	}
+	return pellets
+}
+
+// play runs a whole game between agents (agents[0] is player 0) and returns its outcome. Agents are closed once it's over
+func (ref *Referee) play(agents [2]Agent) (result MatchResult) {
+	defer func() {
+		for _, agent := range agents {
+			if closeAgent, ok := agent.(closer); ok {
+				closeAgent.close()
+			}
+		}
+	}()
+	for player, agent := range agents {
+		result.errors[player] = ref.agentError(agent, ref.initAgent(agent))
+	}
+
+	for result.errors[0] == nil && result.errors[1] == nil && !ref.isOver() {
+		var commands [2]string
+		var orders [2]map[int]pacOrder
+		replayTurn := ReplayTurn{turn: ref.turn, state: ref.state()}
+		for player, agent := range agents {
+			gameData := ref.gameData(player)
+			actions, debugText, err := ref.askAgent(agent, gameData)
+			if err == nil {
+				err = validateActions(actions, gameData)
+			}
+			if err == nil {
+				orders[player] = ordersFor(actions)
+			}
+			command := encodeActions(actions)
+			commands[player] = command
+			result.errors[player] = ref.agentError(agent, err)
+			replayTurn.players = append(replayTurn.players, ReplayPlayerTurn{gameData, command, debugText})
+		}
+		if ref.recorder != nil && result.replayErr == nil {
+			result.replayErr = ref.recorder.recordTurn(replayTurn)
+		}
+		if result.errors[0] != nil || result.errors[1] != nil {
+			break
+		}
+
+		ref.performTurn(orders)
+		result.turns = append(result.turns, ref.snapshot(commands))
+	}
+	if ref.recorder != nil && result.replayErr == nil && result.errors[0] == nil && result.errors[1] == nil {
+		// the state at the start of each turn leaves out how the game ended
+		result.replayErr = ref.recorder.recordTurn(ReplayTurn{turn: ref.turn, state: ref.state()})
+	}
+
+	result.scores = ref.scores
+	result.winner = ref.winner(result.errors)
+	return
+}
+
+func (ref *Referee) initAgent(agent Agent) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic during init: %v", r)
+		}
+	}()
+	if initAgent, ok := agent.(initializer); ok {
+		initAgent.init(ref.gameMap)
+	}
+	return
+}
+
+// agentError returns the reason agent must forfeit, err being the referee's own reason if it has one
+func (ref *Referee) agentError(agent Agent, err error) error {
+	faulty, ok := agent.(faultyAgent)
+	if !ok {
+		return err
+	}
+	if err != nil {
+		return faulty.forfeit(err)
+	}
+	return faulty.fault()
+}
+
+// askAgent returns agent's actions for gameData, along with the debug output it wrote while playing if the game is being recorded
+func (ref *Referee) askAgent(agent Agent, gameData GameData) (actions []Action, debugText string, err error) {
+	previousOutput := debugOutput
+	var captured bytes.Buffer
+	if ref.recorder != nil {
+		debugOutput = &captured
+	}
+	defer func() {
+		debugOutput = previousOutput
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic on turn %v: %v", ref.turn, r)
+		}
+		debugText = captured.String()
+		if reporter, ok := agent.(debugReporter); ok {
+			debugText = reporter.takeDebug()
+		}
+	}()
+	return agent.makeCommand(gameData), "", nil
+}
+
+// ordersFor converts a player's validated actions into orders indexed by pac id
+func ordersFor(actions []Action) map[int]pacOrder {
+	orders := make(map[int]pacOrder)
+	for _, action := range actions {
+		switch action := action.(type) {
+		case Move:
+			orders[action.pacID] = pacOrder{target: action.target, moving: true}
+		case Speed:
+			orders[action.pacID] = pacOrder{ability: "SPEED"}
+		case Switch:
+			orders[action.pacID] = pacOrder{ability: "SWITCH", typeID: action.typeID}
+		}
+	}
+	return orders
+}
+
+// winner returns the index of the winning player, or -1 for a draw. A player that forfeited loses regardless of score
+func (ref *Referee) winner(errors [2]error) int {
+	switch {
+	case errors[0] != nil && errors[1] != nil:
+		return -1
+	case errors[0] != nil:
+		return 1
+	case errors[1] != nil:
+		return 0
+	case ref.scores[0] > ref.scores[1]:
+		return 0
+	case ref.scores[1] > ref.scores[0]:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// isOver returns true once the turn limit is reached or there's nothing left to play for
+func (ref *Referee) isOver() bool {
+	return ref.turn >= maxTurns || len(ref.pellets) == 0 || ref.alivePacCount(0) == 0 || ref.alivePacCount(1) == 0
+}
+
+func (ref *Referee) alivePacCount(player int) (count int) {
+	for _, pac := range ref.pacs {
+		if pac.typeID != deadTypeID && pacOwner(pac) == player {
+			count++
+		}
+	}
+	return
+}
+
+// pacOwner returns the index of the player controlling pac
+func pacOwner(pac Pac) int {
+	if pac.mine {
+		return 0
+	}
+	return 1
+}
+
+// gameData returns the state of the game as seen by player: only the cells in line of sight of one of their pacs that's alive are revealed,
+// except for super pellets. As from the Silver league on, dead pacs are listed with type DEAD: all of player's, and enemies in sight
+func (ref *Referee) gameData(player int) GameData {
+	visible := make(map[Coord]bool)
+	var visiblePacs []Pac
+	for _, pac := range ref.pacs {
+		if pacOwner(pac) != player {
+			continue
+		}
+		if pac.typeID != deadTypeID {
+			for _, coord := range ref.gameMap.VisibleCells(pac.pos) {
+				visible[coord] = true
+			}
+		}
+		pac.mine = true
+		visiblePacs = append(visiblePacs, pac)
+	}
+	for _, pac := range ref.pacs {
+		if pacOwner(pac) != player && visible[pac.pos] {
+			pac.mine = false
+			visiblePacs = append(visiblePacs, pac)
+		}
+	}
+
+	var visiblePellets []Pellet
+	for _, pellet := range ref.sortedPellets() {
+		if visible[pellet.pos] || pellet.value == superPelletValue {
+			visiblePellets = append(visiblePellets, pellet)
+		}
+	}
+
+	return GameData{ref.turn, ref.gameMap, []int{ref.scores[player], ref.scores[1-player]}, visiblePacs, visiblePellets}
+}
+
+func (ref *Referee) sortedPellets() []Pellet {
+	pellets := make([]Pellet, 0, len(ref.pellets))
+	for pos, value := range ref.pellets {
+		pellets = append(pellets, Pellet{pos, value})
+	}
+	sort.Slice(pellets, func(i, j int) bool {
+		return ref.gameMap.GetAbsolutePosition(pellets[i].pos) < ref.gameMap.GetAbsolutePosition(pellets[j].pos)
+	})
+	return pellets
+}
+
+// state returns a copy of the true state of the game
+func (ref *Referee) state() *ReplayState {
+	pacs := make([]Pac, len(ref.pacs))
+	copy(pacs, ref.pacs)
+	return &ReplayState{pacs, ref.sortedPellets(), ref.scores}
+}
+
+func (ref *Referee) snapshot(commands [2]string) RefereeTurn {
+	state := ref.state()
+	return RefereeTurn{ref.turn, state.pacs, state.pellets, state.scores, commands}
+}
+
+// isPacType returns true if typeID is a type a pac that's alive can be
+func isPacType(typeID PacType) bool {
+	for _, pacType := range pacTypes {
+		if typeID == pacType {
+			return true
+		}
+	}
+	return false
+}
+
+// performTurn applies both players' orders: abilities first, then one movement step for every pac and a second one for sped up pacs,
+// each step followed by fights and pellet eating
+func (ref *Referee) performTurn(orders [2]map[int]pacOrder) {
+	usedAbility := make([]bool, len(ref.pacs))
+	paths := make([][]Coord, len(ref.pacs))
+	for i, pac := range ref.pacs {
+		order, found := orders[pacOwner(pac)][pac.id]
+		if !found || pac.typeID == deadTypeID {
+			continue
+		}
+		switch {
+		case order.ability != "" && pac.abilityCooldown == 0:
+			if order.ability == "SPEED" {
+				ref.pacs[i].speedTurnsLeft = speedDuration
+			} else {
+				ref.pacs[i].typeID = order.typeID
+			}
+			ref.pacs[i].abilityCooldown = abilityCooldownDuration
+			usedAbility[i] = true
+		case order.moving:
+			paths[i] = ref.path(pac.pos, order.target)
+		}
+	}
+
+	for step := 0; step < 2; step++ {
+		ref.moveOneStep(paths, step)
+	}
+
+	for i, pac := range ref.pacs {
+		if pac.typeID == deadTypeID {
+			continue
+		}
+		if pac.abilityCooldown > 0 {
+			ref.pacs[i].abilityCooldown--
+		}
+		if pac.speedTurnsLeft > 0 && !usedAbility[i] {
+			ref.pacs[i].speedTurnsLeft--
+		}
+	}
+
+	// a player without pacs can't eat anymore, so the survivor is awarded everything that's left
+	for player := 0; player < 2; player++ {
+		if ref.alivePacCount(player) == 0 && ref.alivePacCount(1-player) > 0 {
+			for pos, value := range ref.pellets {
+				ref.scores[1-player] += value
+				delete(ref.pellets, pos)
+			}
+		}
+	}
+
+	ref.turn++
+}
+
+// moveOneStep advances every pac that may move during this step by one cell along its path, then resolves fights and eats pellets.
+// Pacs of the same player or of the same type block each other: if they would end up on the same cell or swap cells, neither moves.
+func (ref *Referee) moveOneStep(paths [][]Coord, step int) {
+	previous := make([]Coord, len(ref.pacs))
+	next := make([]Coord, len(ref.pacs))
+	for i, pac := range ref.pacs {
+		previous[i], next[i] = pac.pos, pac.pos
+		if pac.typeID != deadTypeID && len(paths[i]) > 0 && (step == 0 || pac.speedTurnsLeft > 0) {
+			next[i] = paths[i][0]
+		}
+	}
+
+	// cancelling a move can block another pac that wanted to move into the vacated cell, so repeat until nothing changes
+	for changed := true; changed; {
+		changed = false
+		for i := range ref.pacs {
+			for j := i + 1; j < len(ref.pacs); j++ {
+				if !ref.blocks(i, j) {
+					continue
+				}
+				collide := next[i] == next[j]
+				swap := next[i] == previous[j] && next[j] == previous[i]
+				if collide || swap {
+					for _, k := range []int{i, j} {
+						if next[k] != previous[k] {
+							next[k] = previous[k]
+							changed = true
+						}
+					}
+				}
+			}
+		}
+	}
+
+	for i := range ref.pacs {
+		if next[i] != previous[i] {
+			ref.pacs[i].pos = next[i]
+			paths[i] = paths[i][1:]
+		} else {
+			// a pac that didn't move this step (blocked or arrived) won't move any further this turn
+			paths[i] = nil
+		}
+	}
+
+	// pacs of different types that end up on the same cell, or that crossed each other, fight: the loser is eaten
+	var eatenPacs []int
+	for i, a := range ref.pacs {
+		for j, b := range ref.pacs {
+			if a.typeID == deadTypeID || b.typeID == deadTypeID || pacOwner(a) == pacOwner(b) {
+				continue
+			}
+			crossed := next[i] == previous[j] && next[j] == previous[i]
+			if (a.pos == b.pos || crossed) && getWinningTypeId(b.typeID) == a.typeID {
+				eatenPacs = append(eatenPacs, j)
+			}
+		}
+	}
+	for _, i := range eatenPacs {
+		ref.pacs[i].typeID = deadTypeID
+		paths[i] = nil
+	}
+
+	eaten := make(map[Coord]bool)
+	for _, pac := range ref.pacs {
+		if value, found := ref.pellets[pac.pos]; found && pac.typeID != deadTypeID {
+			ref.scores[pacOwner(pac)] += value
+			eaten[pac.pos] = true
+		}
+	}
+	for pos := range eaten {
+		delete(ref.pellets, pos)
+	}
+}
+
+// blocks returns true if the pacs at indices i and j are both alive and can't walk through each other
+func (ref *Referee) blocks(i, j int) bool {
+	a, b := ref.pacs[i], ref.pacs[j]
+	if a.typeID == deadTypeID || b.typeID == deadTypeID {
+		return false
+	}
+	return a.mine == b.mine || a.typeID == b.typeID
+}
+
+// path returns the cells a pac at from walks through (from excluded) to reach target along a shortest path.
+// If target can't be reached (a wall, or an isolated area), the pac walks to the reachable cell closest to it instead.
+func (ref *Referee) path(from, target Coord) []Coord {
+	gm := ref.gameMap
+	target = gm.Wrap(target)
+	parents := map[Coord]Coord{from: from}
+	queue := []Coord{from}
+	best := from
+	manhattan := func(c Coord) int {
+		dx, dy := c.x-target.x, c.y-target.y
+		if dx < 0 {
+			dx = -dx
+		}
+		if dy < 0 {
+			dy = -dy
+		}
+		return dx + dy
+	}
+
+	for len(queue) > 0 && best != target {
+		node := queue[0]
+		queue = queue[1:]
+		if manhattan(node) < manhattan(best) {
+			best = node
+		}
+		for _, adjacent := range gm.Neighbors(node) {
+			if _, visited := parents[adjacent]; !visited {
+				parents[adjacent] = node
+				queue = append(queue, adjacent)
+			}
+		}
+	}
+
+	var path []Coord
+	for node := best; node != from; node = parents[node] {
+		path = append([]Coord{node}, path...)
+	}
+	return path
+}
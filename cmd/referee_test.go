@@ -0,0 +1,286 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// corridorMap is a single horizontal corridor from (1,1) to (5,1)
+const corridorMap = `
+#######
+#     #
+#######`
+
+// stayPut is an agent that never issues any command
+var stayPut = agentFunc(func(GameData) []Action { return nil })
+
+func TestRefereeMoveEatsPellets(t *testing.T) {
+	gm := BuildGameMap(corridorMap)
+	pacs := []Pac{{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}, {id: 0, pos: Coord{5, 1}, typeID: "ROCK"}}
+	ref := newReferee(gm, pacs, initialPellets(gm, pacs, nil))
+
+	ref.performTurn([2]map[int]pacOrder{{0: {target: Coord{3, 1}, moving: true}}, {}})
+
+	if expected, actual := (Coord{2, 1}), ref.pacs[0].pos; expected != actual {
+		t.Errorf("expected pac to move to %v, but was at %v", expected, actual)
+	}
+	if expected, actual := [2]int{1, 0}, ref.scores; expected != actual {
+		t.Errorf("expected scores %v, but got %v", expected, actual)
+	}
+	if _, found := ref.pellets[Coord{2, 1}]; found {
+		t.Errorf("expected pellet at (2,1) to be eaten")
+	}
+}
+
+func TestRefereeSpeedMovesTwoCells(t *testing.T) {
+	gm := BuildGameMap(corridorMap)
+	pacs := []Pac{{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}, {id: 0, pos: Coord{5, 1}, typeID: "ROCK"}}
+	ref := newReferee(gm, pacs, initialPellets(gm, pacs, nil))
+
+	ref.performTurn([2]map[int]pacOrder{{0: {ability: "SPEED"}}, {}})
+	if pac := ref.pacs[0]; pac.pos != (Coord{1, 1}) || pac.speedTurnsLeft != speedDuration || pac.abilityCooldown != abilityCooldownDuration-1 {
+		t.Fatalf("expected pac to stay put with speed %v and cooldown %v, but got %+v", speedDuration, abilityCooldownDuration-1, pac)
+	}
+
+	ref.performTurn([2]map[int]pacOrder{{0: {target: Coord{4, 1}, moving: true}}, {}})
+	if pac := ref.pacs[0]; pac.pos != (Coord{3, 1}) || pac.speedTurnsLeft != speedDuration-1 {
+		t.Errorf("expected pac at (3,1) with speed %v, but got %+v", speedDuration-1, pac)
+	}
+	if expected, actual := [2]int{2, 0}, ref.scores; expected != actual {
+		t.Errorf("expected both cells walked over to be eaten, scores %v, but got %v", expected, actual)
+	}
+}
+
+func TestRefereeAbilityOnCooldownIsIgnored(t *testing.T) {
+	gm := BuildGameMap(corridorMap)
+	pacs := []Pac{{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK", abilityCooldown: 3}, {id: 0, pos: Coord{5, 1}, typeID: "ROCK"}}
+	ref := newReferee(gm, pacs, nil)
+
+	ref.performTurn([2]map[int]pacOrder{{0: {ability: "SWITCH", typeID: "PAPER"}}, {}})
+
+	if pac := ref.pacs[0]; pac.typeID != "ROCK" || pac.abilityCooldown != 2 {
+		t.Errorf("expected pac to stay ROCK with cooldown 2, but got %+v", pac)
+	}
+}
+
+func TestRefereeBodyBlocking(t *testing.T) {
+	gm := BuildGameMap(corridorMap)
+	tests := []struct {
+		description string
+		pacs        []Pac
+		orders      [2]map[int]pacOrder
+		expected    []Coord
+	}{
+		{
+			"friendly pacs moving to the same cell",
+			[]Pac{{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}, {id: 1, mine: true, pos: Coord{3, 1}, typeID: "PAPER"}},
+			[2]map[int]pacOrder{{0: {target: Coord{2, 1}, moving: true}, 1: {target: Coord{2, 1}, moving: true}}, {}},
+			[]Coord{{1, 1}, {3, 1}},
+		},
+		{
+			"friendly pacs swapping cells",
+			[]Pac{{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}, {id: 1, mine: true, pos: Coord{2, 1}, typeID: "PAPER"}},
+			[2]map[int]pacOrder{{0: {target: Coord{2, 1}, moving: true}, 1: {target: Coord{1, 1}, moving: true}}, {}},
+			[]Coord{{1, 1}, {2, 1}},
+		},
+		{
+			"enemy pacs of the same type swapping cells",
+			[]Pac{{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}, {id: 0, pos: Coord{2, 1}, typeID: "ROCK"}},
+			[2]map[int]pacOrder{{0: {target: Coord{2, 1}, moving: true}}, {0: {target: Coord{1, 1}, moving: true}}},
+			[]Coord{{1, 1}, {2, 1}},
+		},
+		{
+			"chain reaction behind a blocked pac",
+			[]Pac{{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}, {id: 1, mine: true, pos: Coord{2, 1}, typeID: "ROCK"}, {id: 0, pos: Coord{3, 1}, typeID: "ROCK"}},
+			[2]map[int]pacOrder{{0: {target: Coord{2, 1}, moving: true}, 1: {target: Coord{3, 1}, moving: true}}, {}},
+			[]Coord{{1, 1}, {2, 1}, {3, 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			ref := newReferee(gm, tt.pacs, nil)
+			ref.performTurn(tt.orders)
+			var actual []Coord
+			for _, pac := range ref.pacs {
+				actual = append(actual, pac.pos)
+			}
+			if !reflect.DeepEqual(tt.expected, actual) {
+				t.Errorf("expected pacs at %v, but got %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRefereeFights(t *testing.T) {
+	gm := BuildGameMap(corridorMap)
+	tests := []struct {
+		description string
+		pacs        []Pac
+		orders      [2]map[int]pacOrder
+		expected    []PacType
+	}{
+		{
+			"moving onto a weaker pac",
+			[]Pac{{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}, {id: 0, pos: Coord{2, 1}, typeID: "SCISSORS"}},
+			[2]map[int]pacOrder{{0: {target: Coord{2, 1}, moving: true}}, {}},
+			[]PacType{"ROCK", deadTypeID},
+		},
+		{
+			"moving onto a stronger pac",
+			[]Pac{{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}, {id: 0, pos: Coord{2, 1}, typeID: "PAPER"}},
+			[2]map[int]pacOrder{{0: {target: Coord{2, 1}, moving: true}}, {}},
+			[]PacType{deadTypeID, "PAPER"},
+		},
+		{
+			"crossing paths",
+			[]Pac{{id: 0, mine: true, pos: Coord{1, 1}, typeID: "SCISSORS"}, {id: 0, pos: Coord{2, 1}, typeID: "PAPER"}},
+			[2]map[int]pacOrder{{0: {target: Coord{2, 1}, moving: true}}, {0: {target: Coord{1, 1}, moving: true}}},
+			[]PacType{"SCISSORS", deadTypeID},
+		},
+		{
+			"switching before moving",
+			[]Pac{{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}, {id: 0, pos: Coord{2, 1}, typeID: "PAPER"}},
+			[2]map[int]pacOrder{{0: {target: Coord{2, 1}, moving: true}}, {0: {ability: "SWITCH", typeID: "SCISSORS"}}},
+			[]PacType{"ROCK", deadTypeID},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			ref := newReferee(gm, tt.pacs, nil)
+			ref.performTurn(tt.orders)
+			var actual []PacType
+			for _, pac := range ref.pacs {
+				actual = append(actual, pac.typeID)
+			}
+			if !reflect.DeepEqual(tt.expected, actual) {
+				t.Errorf("expected pac types %v, but got %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRefereeGameDataIsFogged(t *testing.T) {
+	gm := BuildGameMap(`
+#######
+#  #  #
+#######`)
+	pacs := []Pac{{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}, {id: 0, pos: Coord{5, 1}, typeID: "ROCK"}}
+	ref := newReferee(gm, pacs, initialPellets(gm, pacs, []Coord{{4, 1}}))
+
+	gameData := ref.gameData(0)
+
+	if expected, actual := []Pac{pacs[0]}, gameData.visiblePacs; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected only my pac to be visible %v, but got %v", expected, actual)
+	}
+	if expected, actual := []Pellet{{Coord{2, 1}, 1}, {Coord{4, 1}, superPelletValue}}, gameData.visiblePellets; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected pellets in sight and super pellets %v, but got %v", expected, actual)
+	}
+
+	theirs := ref.gameData(1)
+	if len(theirs.visiblePacs) != 1 || !theirs.visiblePacs[0].mine || theirs.visiblePacs[0].pos != (Coord{5, 1}) {
+		t.Errorf("expected player 1 to see only their own pac as mine, but got %v", theirs.visiblePacs)
+	}
+}
+
+func TestRefereeGameDataListsDeadPacs(t *testing.T) {
+	gm := BuildGameMap(`
+#########
+#   #   #
+#########`)
+	pacs := []Pac{
+		{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"},
+		{id: 1, mine: true, pos: Coord{5, 1}, typeID: deadTypeID},
+		{id: 0, pos: Coord{3, 1}, typeID: deadTypeID},
+		{id: 1, pos: Coord{7, 1}, typeID: "PAPER"},
+	}
+	ref := newReferee(gm, pacs, initialPellets(gm, pacs, nil))
+
+	// my dead pac is listed, but doesn't see anything, so the enemy next to it stays hidden
+	expected := []Pac{pacs[0], pacs[1], pacs[2]}
+	if actual := ref.gameData(0).visiblePacs; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected pacs %v, but got %v", expected, actual)
+	}
+
+	theirs := []Pac{pacs[2], pacs[3], pacs[1]}
+	theirs[0].mine, theirs[1].mine, theirs[2].mine = true, true, false
+	if actual := ref.gameData(1).visiblePacs; !reflect.DeepEqual(theirs, actual) {
+		t.Errorf("expected pacs %v, but got %v", theirs, actual)
+	}
+}
+
+func TestRefereeForfeitsInvalidCommands(t *testing.T) {
+	gm := BuildGameMap(corridorMap)
+	pacs := []Pac{{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}, {id: 0, pos: Coord{5, 1}, typeID: "ROCK"}}
+
+	tests := [][]Action{
+		{Move{pacID: 3, target: Coord{2, 1}}},
+		{Switch{pacID: 0, typeID: "LIZARD"}},
+		{Move{pacID: 0, target: Coord{2, 1}}, Speed{pacID: 0}},
+	}
+	for _, actions := range tests {
+		t.Run(encodeActions(actions), func(t *testing.T) {
+			ref := newReferee(gm, pacs, initialPellets(gm, pacs, nil))
+			result := ref.play([2]Agent{agentFunc(func(GameData) []Action { return actions }), stayPut})
+			if result.errors[0] == nil || result.winner != 1 {
+				t.Errorf("expected player 0 to forfeit, but got %+v", result)
+			}
+		})
+	}
+}
+
+func TestRefereeAwardsRemainingPelletsToLastPlayerStanding(t *testing.T) {
+	gm := BuildGameMap(corridorMap)
+	pacs := []Pac{{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}, {id: 0, pos: Coord{2, 1}, typeID: "SCISSORS"}}
+	ref := newReferee(gm, pacs, initialPellets(gm, pacs, nil))
+
+	result := ref.play([2]Agent{agentFunc(func(GameData) []Action { return []Action{Move{pacID: 0, target: Coord{2, 1}}} }), stayPut})
+
+	if expected, actual := 1, len(result.turns); expected != actual {
+		t.Errorf("expected the game to last %v turn, but got %v", expected, actual)
+	}
+	if expected, actual := [2]int{3, 0}, result.scores; expected != actual || result.winner != 0 {
+		t.Errorf("expected scores %v and player 0 to win, but got %v", expected, result)
+	}
+}
+
+func TestRefereePlaysAFullGame(t *testing.T) {
+	gm := BuildGameMap(`
+###################
+#       # #       #
+# ### # # # # ### #
+#   #         #   #
+### # ####### # ###
+        # #        
+### # ####### # ###
+#   #         #   #
+###################`)
+	pacs := []Pac{
+		{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"},
+		{id: 1, mine: true, pos: Coord{1, 7}, typeID: "PAPER"},
+		{id: 0, pos: Coord{17, 1}, typeID: "ROCK"},
+		{id: 1, pos: Coord{17, 7}, typeID: "PAPER"},
+	}
+	pellets := initialPellets(gm, pacs, []Coord{{3, 3}, {15, 3}})
+	ref := newReferee(gm, pacs, pellets)
+
+	result := ref.play([2]Agent{&DansLilHeuristicBot{}, &DansLilHeuristicBot{}})
+
+	if result.errors != [2]error{} {
+		t.Fatalf("expected no errors, but got %v", result.errors)
+	}
+	if len(result.turns) == 0 || len(result.turns) > maxTurns {
+		t.Errorf("expected between 1 and %v turns, but got %v", maxTurns, len(result.turns))
+	}
+	total := 0
+	for _, pellet := range pellets {
+		total += pellet.value
+	}
+	if result.scores[0]+result.scores[1] > total {
+		t.Errorf("expected at most %v points to be scored, but got %v", total, result.scores)
+	}
+	if last := result.turns[len(result.turns)-1]; last.scores != result.scores {
+		t.Errorf("expected the last turn to hold the final scores %v, but got %v", result.scores, last.scores)
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+//-----------------------------------------------------------------------------------
+// Replays: a record of every turn of a game, to reproduce a bot's decisions offline
+//-----------------------------------------------------------------------------------
+
+// replayVersion is the version of the replay format written by ReplayRecorder. Bump it whenever the format changes incompatibly
+const replayVersion = 1
+
+// Replay is a recorded game
+type Replay struct {
+	version int
+	gameMap GameMap
+	turns   []ReplayTurn
+	// final is the state of the game once its last turn was played, or nil unless the referee played the game to its end
+	final *ReplayState
+}
+
+// ReplayTurn is everything that happened during one turn of a recorded game
+type ReplayTurn struct {
+	turn int
+	// state is the full, unfogged state of the game at the start of the turn. It's nil unless the game was played by the referee
+	state *ReplayState
+	// players contains what each bot saw and did this turn, indexed by player. A game recorded by main only has the bot's own player
+	players []ReplayPlayerTurn
+}
+
+// ReplayState is the true state of a game. Pacs belonging to player 0 have mine set to true
+type ReplayState struct {
+	pacs    []Pac
+	pellets []Pellet
+	scores  [2]int
+}
+
+// ReplayPlayerTurn is what a bot saw and did during a turn
+type ReplayPlayerTurn struct {
+	// gameData is the fogged input the bot was given
+	gameData GameData
+	// command is the raw command line the bot answered with
+	command string
+	// debug is everything the bot wrote with debug and debugf (or to stderr, for a bot process) while playing the turn
+	debug string
+}
+
+// The replay file is made of JSON lines: a header holding the map, followed by one line per turn, so that it can be written as the game
+// goes and still be read if the game is cut short. A game the referee played to its end closes with a line holding only its final state
+
+type replayHeaderJSON struct {
+	Version int      `json:"version"`
+	Width   int      `json:"width"`
+	Height  int      `json:"height"`
+	Rows    []string `json:"rows"`
+}
+
+type replayTurnJSON struct {
+	Turn    int                    `json:"turn"`
+	State   *replayStateJSON       `json:"state,omitempty"`
+	Players []replayPlayerTurnJSON `json:"players"`
+}
+
+type replayStateJSON struct {
+	Pacs    []pacJSON    `json:"pacs"`
+	Pellets []pelletJSON `json:"pellets"`
+	Scores  [2]int       `json:"scores"`
+}
+
+type replayPlayerTurnJSON struct {
+	Scores  []int        `json:"scores"`
+	Pacs    []pacJSON    `json:"pacs"`
+	Pellets []pelletJSON `json:"pellets"`
+	Command string       `json:"command"`
+	Debug   string       `json:"debug,omitempty"`
+}
+
+type pacJSON struct {
+	ID              int     `json:"id"`
+	Mine            bool    `json:"mine"`
+	X               int     `json:"x"`
+	Y               int     `json:"y"`
+	TypeID          PacType `json:"type"`
+	SpeedTurnsLeft  int     `json:"speedTurnsLeft"`
+	AbilityCooldown int     `json:"abilityCooldown"`
+}
+
+type pelletJSON struct {
+	X     int `json:"x"`
+	Y     int `json:"y"`
+	Value int `json:"value"`
+}
+
+func toPacsJSON(pacs []Pac) []pacJSON {
+	result := make([]pacJSON, len(pacs))
+	for i, pac := range pacs {
+		result[i] = pacJSON{pac.id, pac.mine, pac.pos.x, pac.pos.y, pac.typeID, pac.speedTurnsLeft, pac.abilityCooldown}
+	}
+	return result
+}
+
+func fromPacsJSON(pacs []pacJSON) (result []Pac) {
+	for _, pac := range pacs {
+		result = append(result, Pac{pac.ID, pac.Mine, Coord{pac.X, pac.Y}, pac.TypeID, pac.SpeedTurnsLeft, pac.AbilityCooldown})
+	}
+	return
+}
+
+func toPelletsJSON(pellets []Pellet) []pelletJSON {
+	result := make([]pelletJSON, len(pellets))
+	for i, pellet := range pellets {
+		result[i] = pelletJSON{pellet.pos.x, pellet.pos.y, pellet.value}
+	}
+	return result
+}
+
+func fromPelletsJSON(pellets []pelletJSON) (result []Pellet) {
+	for _, pellet := range pellets {
+		result = append(result, Pellet{Coord{pellet.X, pellet.Y}, pellet.Value})
+	}
+	return
+}
+
+// ReplayRecorder writes a replay file turn by turn
+type ReplayRecorder struct {
+	encoder *json.Encoder
+}
+
+// newReplayRecorder starts a replay of a game played on gameMap, writing it to w
+func newReplayRecorder(w io.Writer, gameMap GameMap) (*ReplayRecorder, error) {
+	recorder := &ReplayRecorder{json.NewEncoder(w)}
+	header := replayHeaderJSON{replayVersion, gameMap.width, gameMap.height, gameMap.rows()}
+	if err := recorder.encoder.Encode(header); err != nil {
+		return nil, err
+	}
+	return recorder, nil
+}
+
+// recordTurn appends a turn to the replay. A turn without players is the closing state of the game, once its last turn was played
+func (recorder *ReplayRecorder) recordTurn(turn ReplayTurn) error {
+	record := replayTurnJSON{Turn: turn.turn}
+	if turn.state != nil {
+		record.State = &replayStateJSON{toPacsJSON(turn.state.pacs), toPelletsJSON(turn.state.pellets), turn.state.scores}
+	}
+	for _, player := range turn.players {
+		gameData := player.gameData
+		record.Players = append(record.Players, replayPlayerTurnJSON{
+			gameData.scores,
+			toPacsJSON(gameData.visiblePacs),
+			toPelletsJSON(gameData.visiblePellets),
+			player.command,
+			player.debug,
+		})
+	}
+	return recorder.encoder.Encode(record)
+}
+
+// readReplay loads a replay written by ReplayRecorder
+func readReplay(r io.Reader) (*Replay, error) {
+	decoder := json.NewDecoder(r)
+	var header replayHeaderJSON
+	if err := decoder.Decode(&header); err != nil {
+		return nil, fmt.Errorf("invalid replay header: %v", err)
+	}
+	if header.Version != replayVersion {
+		return nil, fmt.Errorf("unsupported replay version %v, expected %v", header.Version, replayVersion)
+	}
+	if len(header.Rows) != header.Height {
+		return nil, fmt.Errorf("invalid replay header: expected %v rows, but got %v", header.Height, len(header.Rows))
+	}
+
+	var cells []Cell
+	for y, row := range header.Rows {
+		if len(row) != header.Width {
+			return nil, fmt.Errorf("invalid replay header: expected row %v to be %v wide, but got %q", y, header.Width, row)
+		}
+		for _, cellValue := range row {
+			cells = append(cells, Cell{cellValue})
+		}
+	}
+	replay := &Replay{version: header.Version, gameMap: newGameMap(header.Width, header.Height, cells)}
+
+	for {
+		var record replayTurnJSON
+		if err := decoder.Decode(&record); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid replay turn after turn %v: %v", len(replay.turns)-1, err)
+		}
+		turn := ReplayTurn{turn: record.Turn}
+		if record.State != nil {
+			turn.state = &ReplayState{fromPacsJSON(record.State.Pacs), fromPelletsJSON(record.State.Pellets), record.State.Scores}
+		}
+		if len(record.Players) == 0 && turn.state != nil {
+			replay.final = turn.state
+			continue
+		}
+		for _, player := range record.Players {
+			gameData := GameData{record.Turn, replay.gameMap, player.Scores, fromPacsJSON(player.Pacs), fromPelletsJSON(player.Pellets)}
+			turn.players = append(turn.players, ReplayPlayerTurn{gameData, player.Command, player.Debug})
+		}
+		replay.turns = append(replay.turns, turn)
+	}
+	return replay, nil
+}
+
+// gameData returns the input player was given on turn
+func (replay *Replay) gameData(turn, player int) (GameData, error) {
+	if turn < 0 || turn >= len(replay.turns) {
+		return GameData{}, fmt.Errorf("turn %v is out of range, the replay has %v turns", turn, len(replay.turns))
+	}
+	players := replay.turns[turn].players
+	if player < 0 || player >= len(players) {
+		return GameData{}, fmt.Errorf("player %v didn't play turn %v", player, turn)
+	}
+	return players[player].gameData, nil
+}
+
+// rerun feeds agent everything player saw up to turn, so that it rebuilds the same memory it had at the time, and returns the actions it
+// takes on turn
+func (replay *Replay) rerun(agent Agent, turn, player int) ([]Action, error) {
+	if turn < 0 || turn >= len(replay.turns) {
+		return nil, fmt.Errorf("turn %v is out of range, the replay has %v turns", turn, len(replay.turns))
+	}
+	if initAgent, ok := agent.(initializer); ok {
+		initAgent.init(replay.gameMap)
+	}
+	var actions []Action
+	for t := 0; t <= turn; t++ {
+		gameData, err := replay.gameData(t, player)
+		if err != nil {
+			return nil, err
+		}
+		actions = agent.makeCommand(gameData)
+	}
+	return actions, nil
+}
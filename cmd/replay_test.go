@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// recordingAgent plays like agent, and remembers every input it was given
+type recordingAgent struct {
+	agent Agent
+	seen  []GameData
+}
+
+func (recording *recordingAgent) makeCommand(gameData GameData) []Action {
+	recording.seen = append(recording.seen, gameData)
+	return recording.agent.makeCommand(gameData)
+}
+
+// countingAgent walks right and tells how many turns it has played so far, which makes its commands depend on its memory
+type countingAgent struct {
+	turns int
+}
+
+func (counting *countingAgent) makeCommand(gameData GameData) []Action {
+	counting.turns++
+	debugf("turn %v\n", counting.turns)
+	pac := gameData.visiblePacs[0]
+	return []Action{Move{pac.id, Coord{pac.pos.x + 1, pac.pos.y}, fmt.Sprint(counting.turns)}}
+}
+
+func recordCorridorGame(t *testing.T, agents [2]Agent) (MatchResult, *Replay) {
+	var file bytes.Buffer
+	gm := BuildGameMap(corridorMap)
+	pacs := []Pac{{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}, {id: 0, pos: Coord{5, 1}, typeID: "ROCK"}}
+	ref := newReferee(gm, pacs, initialPellets(gm, pacs, nil))
+	var err error
+	if ref.recorder, err = newReplayRecorder(&file, gm); err != nil {
+		t.Fatal(err)
+	}
+
+	result := ref.play(agents)
+	if result.replayErr != nil {
+		t.Fatal(result.replayErr)
+	}
+	replay, err := readReplay(&file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result, replay
+}
+
+func TestReplayRecordsRefereeGames(t *testing.T) {
+	player0 := &recordingAgent{agent: &countingAgent{}}
+	result, replay := recordCorridorGame(t, [2]Agent{player0, stayPut})
+
+	if expected, actual := BuildGameMap(corridorMap), replay.gameMap; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected map %v, but got %v", expected, actual)
+	}
+	if expected, actual := len(result.turns), len(replay.turns); expected != actual {
+		t.Fatalf("expected %v turns, but got %v", expected, actual)
+	}
+	last := result.turns[len(result.turns)-1]
+	if replay.final == nil || replay.final.scores != result.scores || !reflect.DeepEqual(last.pacs, replay.final.pacs) {
+		t.Errorf("expected the replay to close with the final state %+v, but got %+v", last, replay.final)
+	}
+	for turn, seen := range player0.seen {
+		t.Run(fmt.Sprint(turn), func(t *testing.T) {
+			actual, err := replay.gameData(turn, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(seen, actual) {
+				t.Errorf("expected game data %+v, but got %+v", seen, actual)
+			}
+			replayTurn := replay.turns[turn]
+			if expected, actual := result.turns[turn].commands[0], replayTurn.players[0].command; expected != actual {
+				t.Errorf("expected command %q, but got %q", expected, actual)
+			}
+			if expected, actual := fmt.Sprintf("turn %v\n", turn+1), replayTurn.players[0].debug; expected != actual {
+				t.Errorf("expected debug output %q, but got %q", expected, actual)
+			}
+			if replayTurn.state == nil || len(replayTurn.state.pacs) != 2 {
+				t.Errorf("expected the full state of both players' pacs, but got %+v", replayTurn.state)
+			}
+		})
+	}
+}
+
+func TestReplayRerun(t *testing.T) {
+	_, replay := recordCorridorGame(t, [2]Agent{&countingAgent{}, stayPut})
+
+	// the agent's debug output isn't captured outside of the referee
+	defer func(previous io.Writer) { debugOutput = previous }(debugOutput)
+	debugOutput = ioutil.Discard
+
+	turn := len(replay.turns) - 1
+	actions, err := replay.rerun(&countingAgent{}, turn, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := replay.turns[turn].players[0].command, encodeActions(actions); expected != actual {
+		t.Errorf("expected the rerun to issue %q, but got %q", expected, actual)
+	}
+
+	if _, err := replay.rerun(&countingAgent{}, turn+1, 0); err == nil {
+		t.Errorf("expected an error when rerunning a turn that wasn't played")
+	}
+}
+
+func TestReadReplayErrors(t *testing.T) {
+	tests := []struct {
+		description string
+		file        string
+	}{
+		{"empty", ""},
+		{"unknown version", `{"version":999,"width":1,"height":1,"rows":["#"]}`},
+		{"inconsistent size", `{"version":1,"width":2,"height":1,"rows":["#"]}`},
+		{"truncated turn", `{"version":1,"width":1,"height":1,"rows":["#"]}` + "\n" + `{"turn":0,"players":[{"sco`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			if _, err := readReplay(strings.NewReader(tt.file)); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+//-----------------------------------------------------------------------------------
+// Route planner: the cells a pac walks over on its way to its target, chosen to eat as many pellets as possible
+//-----------------------------------------------------------------------------------
+
+const (
+	// routeHorizon is how many cells ahead routes are planned
+	routeHorizon = 12
+	// routeBeamWidth is how many partial routes the search keeps after each step
+	routeBeamWidth = 32
+	// routeDiscount is how much less a pellet is worth for every step it takes to reach it
+	routeDiscount = 0.95
+	// targetProgressValue is what every step closer to the target is worth, in pellets
+	targetProgressValue = 0.5
+)
+
+// Route is the cells a pac plans to walk over, in order, its current cell excluded
+type Route struct {
+	steps []Coord
+	// value is the discounted pellet value eaten along the route
+	value float64
+}
+
+// RoutePlanner plans routes with a beam search
+type RoutePlanner struct {
+	gameMap   GameMap
+	distances *DistanceTable
+}
+
+func newRoutePlanner(gameMap GameMap, distances *DistanceTable) *RoutePlanner {
+	return &RoutePlanner{gameMap: gameMap, distances: distances}
+}
+
+// plan returns the route for pac that scores best over routeHorizon steps: pellets eaten along the way (each counted once), plus progress
+// towards target. Routes never enter the cells in avoid, and progress is measured along the way around them, with none to be made if
+// they cut pac off from target. The route is empty if pac can't move at all. Once deadline expires, the search stops and returns the
+// best route it found so far, which is at least one step long
+func (planner *RoutePlanner) plan(pac Pac, target Coord, pellets *PelletBeliefs, avoid map[Coord]bool, deadline *Deadline) Route {
+	gm := planner.gameMap
+	type partialRoute struct {
+		steps []int
+		value float64
+		score float64
+	}
+	start := gm.GetAbsolutePosition(pac.pos)
+	distanceToTarget := func(coord Coord) int { return planner.distances.distance(coord, target) }
+	if len(avoid) > 0 {
+		detours := planner.distances.distancesAvoiding(target, avoid)
+		distanceToTarget = func(coord Coord) int { return detours[gm.GetAbsolutePosition(coord)] }
+	}
+	startDistance, progressValue := distanceToTarget(pac.pos), targetProgressValue
+	if avoid[target] || startDistance == unreachable {
+		progressValue = 0
+	}
+	visited := func(route partialRoute, pos int) bool {
+		if pos == start {
+			return true
+		}
+		for _, step := range route.steps {
+			if step == pos {
+				return true
+			}
+		}
+		return false
+	}
+
+	beam := []partialRoute{{}}
+	best := partialRoute{score: math.Inf(-1)}
+	for depth := 0; depth < routeHorizon; depth++ {
+		if depth > 0 && deadline.expired() {
+			break
+		}
+		// only the best route to each cell survives, to keep the beam diverse
+		bestTo := make(map[int]partialRoute)
+		for _, route := range beam {
+			from := start
+			if len(route.steps) > 0 {
+				from = route.steps[len(route.steps)-1]
+			}
+			for _, next := range gm.NeighborPositions(from) {
+				coord := gm.GetCoord(next)
+				if avoid[coord] {
+					continue
+				}
+				value := route.value
+				if !visited(route, next) {
+					value += math.Pow(routeDiscount, float64(depth)) * pellets.expectedValue(coord)
+				}
+				progress := 0
+				if progressValue > 0 {
+					progress = startDistance - distanceToTarget(coord)
+				}
+				extended := partialRoute{append(append([]int(nil), route.steps...), next), value, value + progressValue*float64(progress)}
+				if previous, found := bestTo[next]; !found || extended.score > previous.score {
+					bestTo[next] = extended
+				}
+			}
+		}
+		if len(bestTo) == 0 {
+			break
+		}
+
+		beam = beam[:0]
+		for _, route := range bestTo {
+			beam = append(beam, route)
+		}
+		sort.Slice(beam, func(i, j int) bool {
+			if beam[i].score != beam[j].score {
+				return beam[i].score > beam[j].score
+			}
+			// map iteration order is random, so break ties by position to stay deterministic
+			return beam[i].steps[len(beam[i].steps)-1] < beam[j].steps[len(beam[j].steps)-1]
+		})
+		if len(beam) > routeBeamWidth {
+			beam = beam[:routeBeamWidth]
+		}
+		if beam[0].score > best.score {
+			best = beam[0]
+		}
+	}
+
+	route := Route{value: best.value}
+	for _, step := range best.steps {
+		route.steps = append(route.steps, gm.GetCoord(step))
+	}
+	return route
+}
+
+// nextTarget returns the MOVE target that makes the server walk pac along route this turn
+func (planner *RoutePlanner) nextTarget(pac Pac, route Route) Coord {
+	return moveTarget(planner.gameMap, planner.distances, pac, route.steps)
+}
+
+// moveTarget returns the MOVE target that makes the server walk pac along steps this turn: the second step when the pac is sped up and
+// there's only one way to get there in two moves, the first step otherwise, or the pac's own cell without any step
+func moveTarget(gameMap GameMap, distances *DistanceTable, pac Pac, steps []Coord) Coord {
+	if len(steps) == 0 {
+		return pac.pos
+	}
+	if pac.speedTurnsLeft > 0 && len(steps) > 1 {
+		ways := 0
+		for _, neighbor := range gameMap.Neighbors(pac.pos) {
+			if distances.distance(neighbor, steps[1]) == 1 {
+				ways++
+			}
+		}
+		if ways == 1 && distances.distance(pac.pos, steps[1]) == 2 {
+			return steps[1]
+		}
+	}
+	return steps[0]
+}
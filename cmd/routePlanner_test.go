@@ -0,0 +1,116 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRoutePlannerGoesThroughPellets(t *testing.T) {
+	gameMap := BuildGameMap(ringMap)
+	planner := newRoutePlanner(gameMap, newDistanceTable(gameMap))
+	pac := Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}
+	// both ways around the ring to (7,3) are as long, but only the one through the bottom row has pellets
+	pellets := pelletsOn(gameMap, Coord{1, 2}, Coord{2, 3}, Coord{3, 3}, Coord{4, 3}, Coord{5, 3})
+
+	tests := []struct {
+		name          string
+		avoid         map[Coord]bool
+		expectedFirst Coord
+	}{
+		{"pellets", nil, Coord{1, 2}},
+		{"avoiding a pac in the way", map[Coord]bool{{1, 2}: true}, Coord{2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route := planner.plan(pac, Coord{7, 3}, pellets, tt.avoid, nil)
+			if len(route.steps) == 0 || route.steps[0] != tt.expectedFirst {
+				t.Fatalf("expected the route to start with %v, but got %v", tt.expectedFirst, route.steps)
+			}
+			for _, step := range route.steps {
+				if tt.avoid[step] {
+					t.Errorf("expected the route to avoid %v, but got %v", step, route.steps)
+				}
+			}
+		})
+	}
+}
+
+func TestRoutePlannerMeasuresProgressAroundAvoidedCells(t *testing.T) {
+	gameMap := BuildGameMap(`
+###############
+#             #
+# ########### #
+#             #
+###############`)
+	planner := newRoutePlanner(gameMap, newDistanceTable(gameMap))
+	pac := Pac{id: 0, mine: true, pos: Coord{3, 3}, typeID: "ROCK"}
+	avoid := map[Coord]bool{{2, 1}: true}
+
+	// (3,1) is closer to the left, but that way is blocked at (2,1). The way round to the right is longer than a route, yet it's the only
+	// one that gets the pac any closer
+	route := planner.plan(pac, Coord{3, 1}, pelletsOn(gameMap), avoid, nil)
+
+	if expected := (Coord{4, 3}); len(route.steps) == 0 || route.steps[0] != expected {
+		t.Errorf("expected the route to start with %v, but got %v", expected, route.steps)
+	}
+}
+
+func TestRoutePlannerCountsPelletsOnce(t *testing.T) {
+	gameMap := BuildGameMap(corridorMap)
+	planner := newRoutePlanner(gameMap, newDistanceTable(gameMap))
+	pac := Pac{id: 0, mine: true, pos: Coord{3, 1}, typeID: "ROCK"}
+
+	// walking back and forth over a pellet doesn't eat it twice, so the route has to go both ways to eat all three
+	route := planner.plan(pac, Coord{3, 1}, pelletsOn(gameMap, Coord{4, 1}, Coord{1, 1}, Coord{2, 1}), nil, nil)
+
+	if expected, actual := 3.0, route.value; actual > expected {
+		t.Errorf("expected the route to eat at most %v pellets, but got %v along %v", expected, actual, route.steps)
+	}
+	if expected := []Coord{{2, 1}, {1, 1}, {2, 1}, {3, 1}, {4, 1}}; !reflect.DeepEqual(expected, route.steps[:5]) {
+		t.Errorf("expected the route to start with %v, but got %v", expected, route.steps)
+	}
+}
+
+func TestRoutePlannerNextTarget(t *testing.T) {
+	gameMap := BuildGameMap(`
+######
+#    #
+#    #
+######`)
+	planner := newRoutePlanner(gameMap, newDistanceTable(gameMap))
+
+	tests := []struct {
+		name     string
+		speed    int
+		steps    []Coord
+		expected Coord
+	}{
+		{"no route", 0, nil, Coord{1, 1}},
+		{"one step at a time", 0, []Coord{{2, 1}, {3, 1}}, Coord{2, 1}},
+		{"two steps when sped up", 3, []Coord{{2, 1}, {3, 1}}, Coord{3, 1}},
+		{"one step when the server could take another way", 3, []Coord{{2, 1}, {2, 2}}, Coord{2, 1}},
+		{"one step when the route turns back", 3, []Coord{{2, 1}, {1, 1}}, Coord{2, 1}},
+		{"one step when the route ends", 3, []Coord{{2, 1}}, Coord{2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pac := Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK", speedTurnsLeft: tt.speed}
+			if actual := planner.nextTarget(pac, Route{steps: tt.steps}); tt.expected != actual {
+				t.Errorf("expected %v, but got %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRoutePlannerStopsAtTheDeadline(t *testing.T) {
+	gameMap := BuildGameMap(ringMap)
+	planner := newRoutePlanner(gameMap, newDistanceTable(gameMap))
+	pac := Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}
+	expired := arenaTimeBudget.deadline(false, time.Now().Add(-time.Second))
+
+	route := planner.plan(pac, Coord{7, 3}, pelletsOn(gameMap, Coord{1, 2}, Coord{1, 3}), nil, expired)
+	if expected := []Coord{{1, 2}}; !reflect.DeepEqual(expected, route.steps) {
+		t.Errorf("expected only the first step to be planned, but got %v", route.steps)
+	}
+}
@@ -6,21 +6,35 @@ import (
 )
 
 func TestSortCoordsByProximity(t *testing.T) {
-	pos := Coord{10, 10}
+	gameMap := BuildGameMap(`
+#########
+#   #   #
+#       #
+#########`)
+	distances := newDistanceTable(gameMap)
+	pos := Coord{3, 1}
+	pellets := newPelletBeliefs(gameMap, distances)
+	pellets.observe(GameData{gameMap: gameMap, scores: []int{0, 0}, visiblePellets: []Pellet{{Coord{7, 1}, superPelletValue}}})
+	unlikely := newPelletBeliefs(gameMap, distances)
+	unlikely.probabilities[gameMap.GetAbsolutePosition(Coord{2, 1})] = 0.25
+
 	tests := []struct {
 		coords   []Coord
-		pellets  map[Coord]int
+		pellets  *PelletBeliefs
 		expected Coord
 	}{
-		{[]Coord{{10, 11}, {10, 12}}, map[Coord]int{}, Coord{10, 11}},
-		{[]Coord{{10, 12}, {10, 11}}, map[Coord]int{}, Coord{10, 11}},
-		{[]Coord{{13, 13}, {9, 9}}, map[Coord]int{}, Coord{9, 9}},
-		{[]Coord{{10, 11}, {10, 5}}, map[Coord]int{{10, 11}: 1, {10, 5}: 10}, Coord{10, 5}},
+		{[]Coord{{2, 1}, {1, 1}}, pellets, Coord{2, 1}},
+		{[]Coord{{1, 1}, {2, 1}}, pellets, Coord{2, 1}},
+		// (5,1) is closer as the crow flies, but there's a wall in the way
+		{[]Coord{{5, 1}, {1, 2}}, pellets, Coord{1, 2}},
+		{[]Coord{{2, 1}, {7, 1}}, pellets, Coord{7, 1}},
+		// a pellet that's probably gone isn't worth the trip, even if it's closer
+		{[]Coord{{2, 1}, {1, 1}}, unlikely, Coord{1, 1}},
 	}
 
 	for i, tt := range tests {
 		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
-			sortCoords(tt.coords, pos, tt.pellets)
+			sortCoords(tt.coords, pos, tt.pellets, distances)
 			if tt.coords[0] != tt.expected {
 				t.Errorf("expected first element to be %v, but was %v", tt.expected, tt.coords[0])
 			}
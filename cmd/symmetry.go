@@ -0,0 +1,56 @@
+package main
+
+//-----------------------------------------------------------------------------------
+// Symmetry: contest maps are mirrored, and so are the starting positions of the pacs
+//-----------------------------------------------------------------------------------
+
+// Mirror returns the cell mirroring coord across the vertical axis in the middle of the map
+func (gm GameMap) Mirror(coord Coord) Coord {
+	coord = gm.Wrap(coord)
+	return Coord{gm.width - 1 - coord.x, coord.y}
+}
+
+// IsSymmetric returns true if every cell of the map is the same as its mirror
+func (gm GameMap) IsSymmetric() bool {
+	for pos, cell := range gm.cells {
+		if gm.GetCell(gm.Mirror(gm.GetCoord(pos))) != cell {
+			return false
+		}
+	}
+	return true
+}
+
+// mirroredEnemies infers where the opponent's pacs started from the first turn's input, when the map is symmetric: each of my pacs has an
+// enemy twin with the same id and type on its mirror cell. Twins we can see (or should be able to see), and pacs standing on the middle
+// column (which are their own mirror), are left out
+func mirroredEnemies(gameData GameData) []Pac {
+	gm := gameData.gameMap
+	if !gm.IsSymmetric() {
+		return nil
+	}
+	visible := make(map[int]bool)
+	inSight := make(map[Coord]bool)
+	for _, pac := range gameData.visiblePacs {
+		if !pac.mine {
+			visible[pac.id] = true
+			continue
+		}
+		if pac.typeID == deadTypeID {
+			continue
+		}
+		for _, coord := range gm.VisibleCells(pac.pos) {
+			inSight[coord] = true
+		}
+	}
+
+	var enemies []Pac
+	for _, pac := range gameData.visiblePacs {
+		if !pac.mine || pac.typeID == deadTypeID || visible[pac.id] || inSight[gm.Mirror(pac.pos)] {
+			continue
+		}
+		twin := pac
+		twin.mine, twin.pos = false, gm.Mirror(pac.pos)
+		enemies = append(enemies, twin)
+	}
+	return enemies
+}
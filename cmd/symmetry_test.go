@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+const symmetricMap = `
+###########
+#    #    #
+# ## # ## #
+#         #
+###########`
+
+func TestMirror(t *testing.T) {
+	gm := BuildGameMap(symmetricMap)
+	tests := []struct {
+		coord, expected Coord
+	}{
+		{Coord{1, 1}, Coord{9, 1}},
+		{Coord{9, 3}, Coord{1, 3}},
+		{Coord{5, 3}, Coord{5, 3}},
+		{Coord{-1, 1}, Coord{0, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.coord), func(t *testing.T) {
+			if actual := gm.Mirror(tt.coord); tt.expected != actual {
+				t.Errorf("expected %v, but got %v", tt.expected, actual)
+			}
+		})
+	}
+
+	if !gm.IsSymmetric() {
+		t.Errorf("expected the map to be symmetric")
+	}
+	if BuildGameMap(ringMap + "\n# ###   #").IsSymmetric() {
+		t.Errorf("expected a lopsided map not to be symmetric")
+	}
+}
+
+func TestMirroredEnemies(t *testing.T) {
+	gm := BuildGameMap(symmetricMap)
+	gameData := GameData{gameMap: gm, scores: []int{0, 0}, visiblePacs: []Pac{
+		{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"},
+		{id: 1, mine: true, pos: Coord{3, 3}, typeID: "PAPER", abilityCooldown: 2},
+		{id: 2, mine: true, pos: Coord{4, 1}, typeID: "SCISSORS"},
+	}}
+
+	// pac 1 can see its twin's cell, and pac 2's twin is hidden behind the wall in the middle
+	expected := []Pac{
+		{id: 0, pos: Coord{9, 1}, typeID: "ROCK"},
+		{id: 2, pos: Coord{6, 1}, typeID: "SCISSORS"},
+	}
+	if actual := mirroredEnemies(gameData); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected enemies %+v, but got %+v", expected, actual)
+	}
+
+	if actual := mirroredEnemies(GameData{gameMap: BuildGameMap(ringMap + "\n# ###   #"), visiblePacs: gameData.visiblePacs}); actual != nil {
+		t.Errorf("expected nothing to be inferred on a lopsided map, but got %+v", actual)
+	}
+}
+
+func TestEnemyTrackerStartsWithMirroredEnemies(t *testing.T) {
+	gm := BuildGameMap(symmetricMap)
+	tracker := newEnemyTracker(gm)
+	me := Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}
+
+	tracker.observe(GameData{0, gm, []int{0, 0}, []Pac{me}, nil})
+
+	if sighting := tracker.enemies[0].sighting; sighting.pac.pos != (Coord{9, 1}) || sighting.pac.typeID != "ROCK" || sighting.round != 0 {
+		t.Errorf("expected the enemy to start at (9,1) as a ROCK, but got %+v", sighting)
+	}
+	if counts := tracker.typeCounts(); len(counts) != 0 {
+		t.Errorf("expected an enemy we haven't seen yet not to be counted, but got %v", counts)
+	}
+	if expected, actual := []Coord{{9, 1}}, tracker.possiblePositions(0); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected the enemy to be at %v, but got %v", expected, actual)
+	}
+}
+
+func TestPelletBeliefsMirrorTheFirstTurn(t *testing.T) {
+	gm := BuildGameMap(symmetricMap)
+	beliefs := newPelletBeliefs(gm, newDistanceTable(gm))
+	me := Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}
+
+	// (2,1) has already been eaten, somehow
+	beliefs.observe(GameData{0, gm, []int{0, 0}, []Pac{me}, []Pellet{{Coord{3, 1}, 1}, {Coord{4, 1}, 1}, {Coord{1, 2}, 1}, {Coord{1, 3}, 1}}})
+
+	tests := []struct {
+		coord    Coord
+		expected float64
+	}{
+		{Coord{9, 1}, 0},
+		{Coord{8, 1}, 0},
+		{Coord{7, 1}, 1},
+		{Coord{9, 3}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.coord), func(t *testing.T) {
+			if actual := beliefs.probability(tt.coord); tt.expected != actual {
+				t.Errorf("expected probability %v, but got %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestPelletBeliefsMirrorTheOpeningTurns(t *testing.T) {
+	gm := BuildGameMap(symmetricMap)
+	beliefs := newPelletBeliefs(gm, newDistanceTable(gm))
+	me := Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}
+
+	beliefs.observe(GameData{0, gm, []int{0, 0}, []Pac{me}, []Pellet{{Coord{2, 1}, 1}, {Coord{3, 1}, 1}, {Coord{4, 1}, 1}, {Coord{1, 2}, 1}, {Coord{1, 3}, 1}}})
+	// a turn later, (3,1) may have been eaten by my pac, but (4,1) is out of every pac's reach
+	beliefs.observe(GameData{1, gm, []int{0, 0}, []Pac{me}, []Pellet{{Coord{2, 1}, 1}, {Coord{1, 2}, 1}, {Coord{1, 3}, 1}}})
+
+	if expected, actual := 1.0, beliefs.probability(Coord{7, 1}); expected != actual {
+		t.Errorf("expected the mirror of a cell a pac may have emptied to be untouched, but got probability %v", actual)
+	}
+	if expected, actual := 0.0, beliefs.probability(Coord{6, 1}); expected != actual {
+		t.Errorf("expected the mirror of a cell that started out empty to be empty, but got probability %v", actual)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import "math"
+
+//-----------------------------------------------------------------------------------
+// Task allocation: which pellets each of my pacs goes after, so that they don't all chase the same ones
+//-----------------------------------------------------------------------------------
+
+const (
+	// stickiness scales down the cost of the cluster a pac was assigned last turn, so that it doesn't give up on it over a small change
+	stickiness = 0.75
+	// enemyTerritoryPenalty scales up the cost of a cluster whose best cell an enemy is going to reach first
+	enemyTerritoryPenalty = 3
+	// unassignedCost is the cost of leaving a pac without a cluster, when there are more pacs than clusters with pellets left
+	unassignedCost = 1e9
+)
+
+// TaskAllocator assigns each of my pacs a cluster of pellets to eat, minimizing how far they walk in total. Clusters are the
+// corridors and junctions of the maze, so that pacs spread out over different corridors instead of walking single file
+type TaskAllocator struct {
+	maze      *MazeGraph
+	distances *DistanceTable
+	// previous holds the cluster each pac was assigned last turn, by pac id
+	previous map[int]int
+}
+
+func newTaskAllocator(maze *MazeGraph, distances *DistanceTable) *TaskAllocator {
+	return &TaskAllocator{maze: maze, distances: distances, previous: make(map[int]int)}
+}
+
+// reconsider drops the cluster the pac with id was assigned last turn, so that it no longer sticks to it: a pac that keeps getting
+// blocked on its way there may be better off elsewhere
+func (allocator *TaskAllocator) reconsider(id int) {
+	delete(allocator.previous, id)
+}
+
+// clusterOf returns the cluster coord belongs to: the id of its corridor, or the number of corridors plus the id of its junction
+func (allocator *TaskAllocator) clusterOf(coord Coord) int {
+	if corridor, _, ok := allocator.maze.corridorAt(coord); ok {
+		return corridor.id
+	}
+	junction, _ := allocator.maze.junctionAt(coord)
+	return len(allocator.maze.corridors) + junction.id
+}
+
+// assign returns the cell each of pacs should head for, by pac id, steering them away from enemy territories unless territories is nil.
+// Pacs in blocked gave up on getting through a cell: they're only sent where they can walk without entering it. Dead pacs are ignored,
+// and pacs left without a cluster (because there are fewer clusters with pellets than pacs) aren't in the result
+func (allocator *TaskAllocator) assign(pacs []Pac, pellets *PelletBeliefs, territories *Territories, blocked map[int]Coord) map[int]Coord {
+	var alive []Pac
+	for _, pac := range pacs {
+		if pac.typeID != deadTypeID {
+			alive = append(alive, pac)
+		}
+	}
+
+	var clusters []int
+	cells := make(map[int][]Coord)
+	pellets.forEachPellet(func(coord Coord, _ float64) {
+		cluster := allocator.clusterOf(coord)
+		if _, found := cells[cluster]; !found {
+			clusters = append(clusters, cluster)
+		}
+		cells[cluster] = append(cells[cluster], coord)
+	})
+
+	// every pac goes for the best cell of its cluster, so a cluster costs what walking to that cell costs per point expected there
+	columns := len(clusters)
+	if columns < len(alive) {
+		columns = len(alive)
+	}
+	costs := make([][]float64, len(alive))
+	targets := make([][]Coord, len(alive))
+	for i, pac := range alive {
+		costs[i], targets[i] = make([]float64, columns), make([]Coord, len(clusters))
+		for j := range costs[i] {
+			costs[i][j] = unassignedCost
+		}
+		distance := func(coord Coord) int { return allocator.distances.distance(pac.pos, coord) }
+		if cell, found := blocked[pac.id]; found {
+			detours := allocator.distances.distancesAvoiding(pac.pos, map[Coord]bool{cell: true})
+			distance = func(coord Coord) int { return detours[allocator.maze.gameMap.GetAbsolutePosition(coord)] }
+		}
+		for j, cluster := range clusters {
+			area := append([]Coord(nil), cells[cluster]...)
+			sortCoords(area, pac.pos, pellets, allocator.distances)
+			// the best cell the pac can get to, which is the first one unless it's blocked
+			for len(area) > 0 && distance(area[0]) == unreachable {
+				area = area[1:]
+			}
+			if len(area) == 0 {
+				continue
+			}
+			targets[i][j] = area[0]
+			costs[i][j] = float64(distance(area[0])+1) / pellets.expectedValue(area[0])
+			if previous, found := allocator.previous[pac.id]; found && previous == cluster {
+				costs[i][j] *= stickiness
+			}
+			if territories != nil && territories.isEnemyTerritory(area[0]) {
+				costs[i][j] *= enemyTerritoryPenalty
+			}
+		}
+	}
+
+	assignment := make(map[int]Coord)
+	allocator.previous = make(map[int]int)
+	for i, j := range minCostAssignment(costs) {
+		if j < len(clusters) && costs[i][j] < unassignedCost {
+			assignment[alive[i].id] = targets[i][j]
+			allocator.previous[alive[i].id] = clusters[j]
+		}
+	}
+	return assignment
+}
+
+// minCostAssignment solves the assignment problem with the Hungarian algorithm: it returns the column assigned to each row of costs,
+// minimizing the sum of their costs. Every row must have as many columns, and there can't be more rows than columns
+func minCostAssignment(costs [][]float64) []int {
+	n := len(costs)
+	if n == 0 {
+		return nil
+	}
+	m := len(costs[0])
+	// rows and columns are numbered from 1 below, column 0 standing for the row being added
+	u, v := make([]float64, n+1), make([]float64, m+1)
+	rowOf, way := make([]int, m+1), make([]int, m+1)
+	for row := 1; row <= n; row++ {
+		rowOf[0] = row
+		column := 0
+		minSlack, used := make([]float64, m+1), make([]bool, m+1)
+		for j := range minSlack {
+			minSlack[j] = math.Inf(1)
+		}
+		for rowOf[column] != 0 {
+			used[column] = true
+			current, delta, next := rowOf[column], math.Inf(1), 0
+			for j := 1; j <= m; j++ {
+				if used[j] {
+					continue
+				}
+				if slack := costs[current-1][j-1] - u[current] - v[j]; slack < minSlack[j] {
+					minSlack[j], way[j] = slack, column
+				}
+				if minSlack[j] < delta {
+					delta, next = minSlack[j], j
+				}
+			}
+			for j := 0; j <= m; j++ {
+				if used[j] {
+					u[rowOf[j]] += delta
+					v[j] -= delta
+				} else {
+					minSlack[j] -= delta
+				}
+			}
+			column = next
+		}
+		// flip the augmenting path
+		for column != 0 {
+			previous := way[column]
+			rowOf[column] = rowOf[previous]
+			column = previous
+		}
+	}
+
+	assignment := make([]int, n)
+	for j := 1; j <= m; j++ {
+		if rowOf[j] != 0 {
+			assignment[rowOf[j]-1] = j - 1
+		}
+	}
+	return assignment
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// teeMap has a junction at (3,1), between corridors going left, right, and down
+const teeMap = `
+#######
+#     #
+### ###
+### ###
+#######`
+
+// pelletsOn returns beliefs that there's a pellet on each of coords, and nowhere else
+func pelletsOn(gameMap GameMap, coords ...Coord) *PelletBeliefs {
+	beliefs := newPelletBeliefs(gameMap, newDistanceTable(gameMap))
+	for pos := range beliefs.probabilities {
+		beliefs.probabilities[pos] = 0
+	}
+	for _, coord := range coords {
+		beliefs.probabilities[gameMap.GetAbsolutePosition(coord)] = 1
+	}
+	return beliefs
+}
+
+func TestMinCostAssignment(t *testing.T) {
+	tests := []struct {
+		costs    [][]float64
+		expected []int
+	}{
+		{nil, nil},
+		{[][]float64{{4, 1, 3}, {2, 0, 5}, {3, 2, 2}}, []int{1, 0, 2}},
+		{[][]float64{{1, 2, 3}, {1, 4, 9}}, []int{1, 0}},
+		{[][]float64{{7, 3}}, []int{1}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.costs), func(t *testing.T) {
+			if actual := minCostAssignment(tt.costs); !reflect.DeepEqual(tt.expected, actual) {
+				t.Errorf("expected %v, but got %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestTaskAllocatorSpreadsPacsOverClusters(t *testing.T) {
+	gameMap := BuildGameMap(teeMap)
+	allocator := newTaskAllocator(newMazeGraph(gameMap), newDistanceTable(gameMap))
+	pacs := []Pac{{id: 0, mine: true, pos: Coord{5, 1}, typeID: "ROCK"}, {id: 1, mine: true, pos: Coord{4, 1}, typeID: "ROCK"}}
+
+	// both pacs are closer to (2,1), but only one of them should go for it
+	targets := allocator.assign(pacs, pelletsOn(gameMap, Coord{2, 1}, Coord{3, 3}), nil, nil)
+
+	if len(targets) != 2 || targets[0] == targets[1] {
+		t.Errorf("expected the pacs to head for different pellets, but got %v", targets)
+	}
+}
+
+func TestTaskAllocatorSticksToPreviousCluster(t *testing.T) {
+	gameMap := BuildGameMap(teeMap)
+	allocator := newTaskAllocator(newMazeGraph(gameMap), newDistanceTable(gameMap))
+	pacs := []Pac{{id: 0, mine: true, pos: Coord{5, 1}, typeID: "ROCK"}}
+
+	if expected, actual := (map[int]Coord{0: {1, 1}}), allocator.assign(pacs, pelletsOn(gameMap, Coord{1, 1}), nil, nil); !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("expected targets %v, but got %v", expected, actual)
+	}
+
+	// (3,2) is a little closer, but not enough to give up on the left corridor
+	beliefs := pelletsOn(gameMap, Coord{1, 1}, Coord{3, 2})
+	if expected, actual := (map[int]Coord{0: {1, 1}}), allocator.assign(pacs, beliefs, nil, nil); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected targets %v, but got %v", expected, actual)
+	}
+	fresh := newTaskAllocator(newMazeGraph(gameMap), newDistanceTable(gameMap))
+	if expected, actual := (map[int]Coord{0: {3, 2}}), fresh.assign(pacs, beliefs, nil, nil); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected a new allocator to pick targets %v, but got %v", expected, actual)
+	}
+}
+
+func TestTaskAllocatorReconsidersPreviousCluster(t *testing.T) {
+	gameMap := BuildGameMap(teeMap)
+	allocator := newTaskAllocator(newMazeGraph(gameMap), newDistanceTable(gameMap))
+	pacs := []Pac{{id: 0, mine: true, pos: Coord{5, 1}, typeID: "ROCK"}}
+	allocator.assign(pacs, pelletsOn(gameMap, Coord{1, 1}), nil, nil)
+
+	// the pac got stuck on its way to the left corridor, so the closer (3,2) wins
+	allocator.reconsider(0)
+	if expected, actual := (map[int]Coord{0: {3, 2}}), allocator.assign(pacs, pelletsOn(gameMap, Coord{1, 1}, Coord{3, 2}), nil, nil); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected targets %v, but got %v", expected, actual)
+	}
+}
+
+func TestTaskAllocatorIgnoresDeadPacs(t *testing.T) {
+	gameMap := BuildGameMap(teeMap)
+	allocator := newTaskAllocator(newMazeGraph(gameMap), newDistanceTable(gameMap))
+	pacs := []Pac{{id: 0, mine: true, pos: Coord{2, 1}, typeID: deadTypeID}, {id: 1, mine: true, pos: Coord{5, 1}, typeID: "ROCK"}}
+
+	if expected, actual := (map[int]Coord{1: {1, 1}}), allocator.assign(pacs, pelletsOn(gameMap, Coord{1, 1}), nil, nil); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected targets %v, but got %v", expected, actual)
+	}
+}
+
+func TestTaskAllocatorLeavesExtraPacsUnassigned(t *testing.T) {
+	gameMap := BuildGameMap(teeMap)
+	allocator := newTaskAllocator(newMazeGraph(gameMap), newDistanceTable(gameMap))
+	pacs := []Pac{{id: 0, mine: true, pos: Coord{5, 1}, typeID: "ROCK"}, {id: 1, mine: true, pos: Coord{3, 3}, typeID: "ROCK"}}
+
+	if expected, actual := (map[int]Coord{1: {3, 2}}), allocator.assign(pacs, pelletsOn(gameMap, Coord{3, 2}), nil, nil); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected targets %v, but got %v", expected, actual)
+	}
+}
+
+func TestTaskAllocatorSendsBlockedPacsElsewhere(t *testing.T) {
+	gameMap := BuildGameMap(teeMap)
+	allocator := newTaskAllocator(newMazeGraph(gameMap), newDistanceTable(gameMap))
+	pacs := []Pac{{id: 0, mine: true, pos: Coord{2, 1}, typeID: "ROCK"}}
+	beliefs := pelletsOn(gameMap, Coord{5, 1}, Coord{1, 1})
+
+	if expected, actual := (map[int]Coord{0: {1, 1}}), allocator.assign(pacs, beliefs, nil, map[int]Coord{0: {3, 1}}); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected targets %v, but got %v", expected, actual)
+	}
+	// with nowhere else to go, the pac is left without a target
+	if expected, actual := (map[int]Coord{}), allocator.assign(pacs, pelletsOn(gameMap, Coord{5, 1}), nil, map[int]Coord{0: {3, 1}}); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected targets %v, but got %v", expected, actual)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+//-----------------------------------------------------------------------------------
+// Territories: which pac gets to each cell first
+//-----------------------------------------------------------------------------------
+
+// Territories splits the floor of the map between pacs, Voronoi style: each cell goes to the pac that can reach it in the fewest turns,
+// speed boosts included. Cells several pacs reach on the same turn are shared between them
+type Territories struct {
+	gameMap GameMap
+	// pacs holds every pac that claims cells, once each. Pacs are referred to by their index in it
+	pacs []Pac
+	// arrivals holds the turn the first pac reaches each absolute position, or unreachable
+	arrivals []int
+	// owners holds the pacs that reach each absolute position first, which is empty for walls and cells nobody can reach
+	owners [][]int
+}
+
+// newTerritories splits the map between pacs. An enemy out of sight can be listed several times, once on every cell it could be on, and
+// claims cells from whichever of them is nearest. Dead pacs don't claim anything
+func newTerritories(gameMap GameMap, distances *DistanceTable, pacs []Pac) *Territories {
+	territories := &Territories{gameMap: gameMap, arrivals: make([]int, len(gameMap.cells)), owners: make([][]int, len(gameMap.cells))}
+	for pos := range territories.arrivals {
+		territories.arrivals[pos] = unreachable
+	}
+
+	indexes := make(map[pacKey]int)
+	for _, pac := range pacs {
+		if pac.typeID == deadTypeID {
+			continue
+		}
+		index, found := indexes[pacKey{pac.id, pac.mine}]
+		if !found {
+			index = len(territories.pacs)
+			indexes[pacKey{pac.id, pac.mine}] = index
+			territories.pacs = append(territories.pacs, pac)
+		}
+
+		turnsToWalk := arrivalTurns(pac, len(gameMap.cells))
+		gameMap.ForEachFloorCell(func(pos int, coord Coord) {
+			distance := distances.distance(pac.pos, coord)
+			if distance == unreachable {
+				return
+			}
+			switch turns := turnsToWalk[distance]; {
+			case turns < territories.arrivals[pos]:
+				territories.arrivals[pos], territories.owners[pos] = turns, []int{index}
+			case turns == territories.arrivals[pos] && !containsInt(territories.owners[pos], index):
+				territories.owners[pos] = append(territories.owners[pos], index)
+			}
+		})
+	}
+	return territories
+}
+
+// arrivalTurns returns how many turns it takes pac to walk each distance up to maxDistance, speeding up as soon as it can
+func arrivalTurns(pac Pac, maxDistance int) []int {
+	turns := make([]int, maxDistance+1)
+	sighting := enemySighting{pac, 0}
+	turn, steps := 0, 0
+	for distance := 1; distance <= maxDistance; distance++ {
+		for steps < distance {
+			turn++
+			steps += sighting.stepsOnTurn(turn)
+		}
+		turns[distance] = turn
+	}
+	return turns
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ownersOf returns the pacs that reach coord first
+func (territories *Territories) ownersOf(coord Coord) []Pac {
+	var pacs []Pac
+	for _, index := range territories.owners[territories.gameMap.GetAbsolutePosition(territories.gameMap.Wrap(coord))] {
+		pacs = append(pacs, territories.pacs[index])
+	}
+	return pacs
+}
+
+// isEnemyTerritory returns true if only enemy pacs are first to reach coord
+func (territories *Territories) isEnemyTerritory(coord Coord) bool {
+	owners := territories.ownersOf(coord)
+	for _, pac := range owners {
+		if pac.mine {
+			return false
+		}
+	}
+	return len(owners) > 0
+}
+
+// cells returns the cells pac reaches first, shared ones included, in row order
+func (territories *Territories) cells(pac Pac) []Coord {
+	var coords []Coord
+	for pos, owners := range territories.owners {
+		for _, index := range owners {
+			if owner := territories.pacs[index]; owner.id == pac.id && owner.mine == pac.mine {
+				coords = append(coords, territories.gameMap.GetCoord(pos))
+			}
+		}
+	}
+	return coords
+}
+
+// values returns the pellet value we expect in the territory of each pac, in the order of the pacs field. Shared cells are split evenly
+// between the pacs sharing them
+func (territories *Territories) values(pellets *PelletBeliefs) []float64 {
+	values := make([]float64, len(territories.pacs))
+	pellets.forEachPellet(func(coord Coord, _ float64) {
+		owners := territories.owners[territories.gameMap.GetAbsolutePosition(coord)]
+		for _, index := range owners {
+			values[index] += pellets.expectedValue(coord) / float64(len(owners))
+		}
+	})
+	return values
+}
+
+// balance evaluates a position by how much more pellet value lies in my pacs' territories than in the enemies'
+func (territories *Territories) balance(pellets *PelletBeliefs) float64 {
+	balance := 0.0
+	for index, value := range territories.values(pellets) {
+		if territories.pacs[index].mine {
+			balance += value
+		} else {
+			balance -= value
+		}
+	}
+	return balance
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTerritories(t *testing.T) {
+	gameMap := BuildGameMap(corridorMap)
+	me := Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK", abilityCooldown: 10}
+	enemy := Pac{id: 0, pos: Coord{5, 1}, typeID: "ROCK", abilityCooldown: 10}
+	spedUp := enemy
+	spedUp.speedTurnsLeft = 5
+	lurking := enemy
+	lurking.pos = Coord{4, 1}
+
+	tests := []struct {
+		name          string
+		pacs          []Pac
+		expectedMine  []Coord
+		expectedEnemy []Coord
+		expectedTurns []int
+	}{
+		{
+			name:          "the middle cell is shared",
+			pacs:          []Pac{me, enemy},
+			expectedMine:  []Coord{{1, 1}, {2, 1}, {3, 1}},
+			expectedEnemy: []Coord{{3, 1}, {4, 1}, {5, 1}},
+			expectedTurns: []int{0, 1, 2, 1, 0},
+		},
+		{
+			name:          "speed reaches farther",
+			pacs:          []Pac{me, spedUp},
+			expectedMine:  []Coord{{1, 1}, {2, 1}},
+			expectedEnemy: []Coord{{3, 1}, {4, 1}, {5, 1}},
+			expectedTurns: []int{0, 1, 1, 1, 0},
+		},
+		{
+			name:          "an enemy out of sight claims cells from its nearest possible position",
+			pacs:          []Pac{me, enemy, lurking},
+			expectedMine:  []Coord{{1, 1}, {2, 1}},
+			expectedEnemy: []Coord{{3, 1}, {4, 1}, {5, 1}},
+			expectedTurns: []int{0, 1, 1, 0, 0},
+		},
+		{
+			name:          "dead pacs don't claim anything",
+			pacs:          []Pac{me, {id: 0, pos: Coord{5, 1}, typeID: deadTypeID}},
+			expectedMine:  []Coord{{1, 1}, {2, 1}, {3, 1}, {4, 1}, {5, 1}},
+			expectedTurns: []int{0, 1, 2, 3, 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			territories := newTerritories(gameMap, newDistanceTable(gameMap), tt.pacs)
+
+			if actual := territories.cells(me); !reflect.DeepEqual(tt.expectedMine, actual) {
+				t.Errorf("expected my territory %v, but got %v", tt.expectedMine, actual)
+			}
+			if actual := territories.cells(enemy); !reflect.DeepEqual(tt.expectedEnemy, actual) {
+				t.Errorf("expected the enemy territory %v, but got %v", tt.expectedEnemy, actual)
+			}
+			var turns []int
+			for x := 1; x <= 5; x++ {
+				turns = append(turns, territories.arrivals[gameMap.GetAbsolutePosition(Coord{x, 1})])
+			}
+			if !reflect.DeepEqual(tt.expectedTurns, turns) {
+				t.Errorf("expected arrival turns %v, but got %v", tt.expectedTurns, turns)
+			}
+		})
+	}
+}
+
+func TestTerritoriesValues(t *testing.T) {
+	gameMap := BuildGameMap(corridorMap)
+	me := Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK", abilityCooldown: 10}
+	enemy := Pac{id: 0, pos: Coord{5, 1}, typeID: "ROCK", abilityCooldown: 10}
+	territories := newTerritories(gameMap, newDistanceTable(gameMap), []Pac{me, enemy})
+	pellets := pelletsOn(gameMap, Coord{2, 1}, Coord{3, 1}, Coord{4, 1})
+	pellets.superPellets[Coord{2, 1}] = true
+
+	if expected, actual := []float64{10.5, 1.5}, territories.values(pellets); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected territory values %v, but got %v", expected, actual)
+	}
+	if expected, actual := 9.0, territories.balance(pellets); expected != actual {
+		t.Errorf("expected a balance of %v, but got %v", expected, actual)
+	}
+	if !territories.isEnemyTerritory(Coord{4, 1}) || territories.isEnemyTerritory(Coord{3, 1}) {
+		t.Errorf("expected only (4,1) to be enemy territory")
+	}
+}
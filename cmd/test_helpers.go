@@ -2,17 +2,25 @@ package main
 
 import "strings"
 
-func BuildGameMap(cellString string) (gm GameMap) {
+func BuildGameMap(cellString string) GameMap {
 	rows := strings.Split(cellString, "\n")
-	gm.height = len(rows) - 1
+	var width int
+	var cells []Cell
 	for i, r := range rows {
 		if i > 0 {
-			gm.width = len(r)
+			width = len(r)
 			for _, c := range r {
-				gm.cells = append(gm.cells, Cell{c})
+				cells = append(cells, Cell{c})
 			}
 		}
 	}
 
-	return
+	return newGameMap(width, len(rows)-1, cells)
+}
+
+// agentFunc adapts a function to the Agent interface, for scripting a player's actions in tests
+type agentFunc func(GameData) []Action
+
+func (f agentFunc) makeCommand(gameData GameData) []Action {
+	return f(gameData)
 }
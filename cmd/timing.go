@@ -0,0 +1,91 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+//-----------------------------------------------------------------------------------
+// Timing: how long the bot has left to answer a turn
+//-----------------------------------------------------------------------------------
+
+// TimeBudget is how much of the arena's time limits the bot allows itself
+type TimeBudget struct {
+	// firstTurn and turn are the arena's limits for the first turn (initialization included) and every other turn
+	firstTurn, turn time.Duration
+	// margin is kept in reserve to write the command, and to absorb whatever the process scheduler throws at us
+	margin time.Duration
+}
+
+// arenaTimeBudget matches the arena's limits
+var arenaTimeBudget = TimeBudget{firstTurnTimeout, turnTimeout, 10 * time.Millisecond}
+
+// deadline returns the deadline of a turn whose first line was read at start
+func (budget TimeBudget) deadline(firstTurn bool, start time.Time) *Deadline {
+	limit := budget.turn
+	if firstTurn {
+		limit = budget.firstTurn
+	}
+	return &Deadline{start, start.Add(limit - budget.margin), limit}
+}
+
+// Deadline is the time by which an agent must have decided on its actions. Searches should poll it, and return their best answer so
+// far once it has expired. A nil Deadline never expires
+type Deadline struct {
+	start, end time.Time
+	// limit is the arena's limit for the turn, margin included
+	limit time.Duration
+}
+
+// expired returns true once the agent should stop thinking and answer
+func (deadline *Deadline) expired() bool {
+	return deadline != nil && !time.Now().Before(deadline.end)
+}
+
+// remaining returns how long the agent can keep thinking
+func (deadline *Deadline) remaining() time.Duration {
+	if deadline == nil {
+		return math.MaxInt64
+	}
+	if remaining := time.Until(deadline.end); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// elapsed returns how long ago the turn started
+func (deadline *Deadline) elapsed() time.Duration {
+	if deadline == nil {
+		return 0
+	}
+	return time.Since(deadline.start)
+}
+
+// timedAgent is implemented by agents that can make the most of the time they're given
+type timedAgent interface {
+	makeTimedCommand(gameData GameData, deadline *Deadline) []Action
+}
+
+// makeCommandBefore asks agent for its actions, passing deadline along if it knows what to do with it
+func makeCommandBefore(agent Agent, gameData GameData, deadline *Deadline) []Action {
+	if timed, ok := agent.(timedAgent); ok {
+		return timed.makeTimedCommand(gameData, deadline)
+	}
+	return agent.makeCommand(gameData)
+}
+
+// fallbackAction is a command for pac that costs next to nothing to work out, for when there's no time left to think: head for the
+// nearest pellet in sight, or stay put
+func fallbackAction(pac Pac, gameData GameData) Action {
+	target, best := pac.pos, math.MaxInt64
+	for _, pellet := range gameData.visiblePellets {
+		dx, dy := abs(pellet.pos.x-pac.pos.x), abs(pellet.pos.y-pac.pos.y)
+		if wrapped := gameData.gameMap.width - dx; wrapped < dx {
+			dx = wrapped
+		}
+		if distance := dx + dy; distance < best {
+			target, best = pellet.pos, distance
+		}
+	}
+	return Move{pac.id, target, "zzz"}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeadline(t *testing.T) {
+	var unlimited *Deadline
+	if unlimited.expired() || unlimited.remaining() < time.Hour || unlimited.elapsed() != 0 {
+		t.Errorf("expected a nil deadline never to expire")
+	}
+
+	budget := TimeBudget{firstTurn: time.Second, turn: 50 * time.Millisecond, margin: 10 * time.Millisecond}
+	now := time.Now()
+	if deadline := budget.deadline(true, now); deadline.expired() || deadline.remaining() <= 500*time.Millisecond || deadline.limit != time.Second {
+		t.Errorf("expected the first turn to allow up to a second, but got %+v", deadline)
+	}
+	if deadline := budget.deadline(false, now); deadline.remaining() > 40*time.Millisecond || deadline.limit != 50*time.Millisecond {
+		t.Errorf("expected other turns to allow up to 40ms once the margin is taken out, but got %+v", deadline)
+	}
+	if deadline := budget.deadline(false, now.Add(-45*time.Millisecond)); !deadline.expired() || deadline.remaining() != 0 || deadline.elapsed() < 45*time.Millisecond {
+		t.Errorf("expected a deadline to expire within its margin, but got %+v", deadline)
+	}
+}
+
+func TestFallbackAction(t *testing.T) {
+	gameMap := BuildGameMap(`
+#########
+         
+#########`)
+	pac := Pac{id: 2, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}
+
+	// the pellet at (8,1) is 2 cells away through the tunnel
+	gameData := GameData{gameMap: gameMap, visiblePellets: []Pellet{{Coord{4, 1}, 1}, {Coord{8, 1}, 1}}}
+	if expected, actual := (Move{2, Coord{8, 1}, "zzz"}), fallbackAction(pac, gameData); expected != actual {
+		t.Errorf("expected %v, but got %v", expected, actual)
+	}
+	if expected, actual := (Move{2, Coord{1, 1}, "zzz"}), fallbackAction(pac, GameData{gameMap: gameMap}); expected != actual {
+		t.Errorf("expected to stay put without pellets in sight, but got %v", actual)
+	}
+}
+
+func TestBotFallsBackOnceOutOfTime(t *testing.T) {
+	gameMap := BuildGameMap(ringMap)
+	gameData := GameData{
+		gameMap: gameMap,
+		scores:  []int{0, 0},
+		visiblePacs: []Pac{
+			{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"},
+			{id: 1, mine: true, pos: Coord{1, 3}, typeID: "PAPER"},
+		},
+		visiblePellets: []Pellet{{Coord{2, 1}, 1}},
+	}
+	bot := DansLilHeuristicBot{}
+	bot.init(gameMap)
+
+	expired := arenaTimeBudget.deadline(false, time.Now().Add(-time.Second))
+	actions := makeCommandBefore(&bot, gameData, expired)
+
+	if expected, actual := "MOVE 0 2 1 zzz|MOVE 1 2 1 zzz", encodeActions(actions); expected != actual {
+		t.Errorf("expected %q, but got %q", expected, actual)
+	}
+}
+
+func TestPlayGameLogsTimeUsed(t *testing.T) {
+	input := "7 3\n#######\n#     #\n#######\n0 0\n1\n0 1 1 1 ROCK 0 0\n1\n2 1 1\n"
+	defer func(previous io.Writer) { debugOutput = previous }(debugOutput)
+	var stderr bytes.Buffer
+	debugOutput = &stderr
+
+	before := time.Now()
+	reader := newProtocolReader(strings.NewReader(input))
+	if _, err := reader.readInit(); err != nil || reader.blockStart.Before(before) {
+		t.Errorf("expected the reader to note when the block started, but got %v (error %v)", reader.blockStart, err)
+	}
+
+	if err := playGame(&DansLilHeuristicBot{}, strings.NewReader(input), ioutil.Discard, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stderr.String(), "turn 0: answered in ") || !strings.Contains(stderr.String(), " of 1s") {
+		t.Errorf("expected the time used on turn 0 to be logged, but got %q", stderr.String())
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import "sort"
+
+//-----------------------------------------------------------------------------------
+// Traps: cornering an enemy pac in a corridor, and closing in on it from every way out
+//-----------------------------------------------------------------------------------
+
+// TrapRole is what one of my pacs does in a trap
+type TrapRole struct {
+	pac Pac
+	// exit is the junction the pac closes the corridor from
+	exit Coord
+	// chaser is true for the pac that beats the prey and goes in for the kill, false for pacs that only block its way out
+	chaser bool
+}
+
+// Trap is a plan to eat an enemy pac stuck in a corridor: every way out of the corridor is guarded by one of my pacs that the prey can't
+// eat, and at least one of them can eat the prey
+type Trap struct {
+	prey  Pac
+	roles []TrapRole
+}
+
+// TrapFinder looks for enemy pacs that can be cornered
+type TrapFinder struct {
+	gameMap   GameMap
+	distances *DistanceTable
+	maze      *MazeGraph
+}
+
+func newTrapFinder(gameMap GameMap, distances *DistanceTable, maze *MazeGraph) *TrapFinder {
+	return &TrapFinder{gameMap: gameMap, distances: distances, maze: maze}
+}
+
+// escapeSet returns the cells prey can get to before any of guards, in row order
+func (finder *TrapFinder) escapeSet(prey Pac, guards []Pac) []Coord {
+	territories := newTerritories(finder.gameMap, finder.distances, append([]Pac{prey}, guards...))
+	var coords []Coord
+	for _, coord := range territories.cells(prey) {
+		if owners := territories.ownersOf(coord); len(owners) == 1 {
+			coords = append(coords, coord)
+		}
+	}
+	return coords
+}
+
+// find returns the traps my pacs can set on enemies, using each of my pacs at most once. Only enemies in sight, in a corridor with a
+// junction at one end at least, that can't switch type before they're caught, are considered
+func (finder *TrapFinder) find(myPacs []Pac, enemies []Pac) []Trap {
+	var traps []Trap
+	busy := make(map[int]bool)
+	for _, prey := range enemies {
+		if prey.mine || prey.typeID == deadTypeID {
+			continue
+		}
+		if trap, found := finder.trap(prey, myPacs, busy); found {
+			for _, role := range trap.roles {
+				busy[role.pac.id] = true
+			}
+			traps = append(traps, trap)
+		}
+	}
+	return traps
+}
+
+// trap returns a trap on prey set by myPacs that aren't busy, if there's one
+func (finder *TrapFinder) trap(prey Pac, myPacs []Pac, busy map[int]bool) (Trap, bool) {
+	corridor, _, ok := finder.maze.corridorAt(prey.pos)
+	if !ok {
+		return Trap{}, false
+	}
+	var exits []Coord
+	for _, end := range corridor.ends {
+		if end != noJunction {
+			exits = append(exits, finder.maze.junctions[end].pos)
+		}
+	}
+	if len(exits) == 0 {
+		return Trap{}, false
+	}
+
+	// guards are the pacs the prey can't eat
+	var guards []Pac
+	for _, pac := range myPacs {
+		if !busy[pac.id] && pac.typeID != deadTypeID && getWinningTypeId(pac.typeID) != prey.typeID {
+			guards = append(guards, pac)
+		}
+	}
+	escapes := make(map[Coord]bool)
+	for _, coord := range finder.escapeSet(prey, guards) {
+		escapes[coord] = true
+	}
+
+	preyTurns := arrivalTurns(prey, len(finder.gameMap.cells))
+	var trap Trap
+	used := make(map[int]bool)
+	for _, exit := range exits {
+		if escapes[exit] {
+			return Trap{}, false
+		}
+		// the guard nearest to the exit takes it, provided it gets there no later than the prey without having to get past it
+		sort.SliceStable(guards, func(i, j int) bool {
+			return finder.distances.distance(guards[i].pos, exit) < finder.distances.distance(guards[j].pos, exit)
+		})
+		found := false
+		for _, guard := range guards {
+			late := turnsToReach(guard, finder.distances.distance(guard.pos, exit)) > preyTurns[finder.distances.distance(prey.pos, exit)]
+			if used[guard.id] || late || finder.passesBy(guard.pos, exit, prey.pos) {
+				continue
+			}
+			used[guard.id], found = true, true
+			trap.roles = append(trap.roles, TrapRole{guard, exit, getWinningTypeId(prey.typeID) == guard.typeID})
+			break
+		}
+		if !found {
+			return Trap{}, false
+		}
+	}
+
+	// the chaser has to get there before the prey can switch out of it
+	chaser := -1
+	for i, role := range trap.roles {
+		if role.chaser && (chaser < 0 || finder.distances.distance(role.pac.pos, prey.pos) < finder.distances.distance(trap.roles[chaser].pac.pos, prey.pos)) {
+			chaser = i
+		}
+	}
+	if chaser < 0 || prey.abilityCooldown < turnsToReach(trap.roles[chaser].pac, finder.distances.distance(trap.roles[chaser].pac.pos, prey.pos)) {
+		return Trap{}, false
+	}
+	for i := range trap.roles {
+		trap.roles[i].chaser = i == chaser
+	}
+	trap.prey = prey
+	return trap, true
+}
+
+// passesBy returns true if a shortest path from from to to goes through via
+func (finder *TrapFinder) passesBy(from, to, via Coord) bool {
+	return finder.distances.distance(from, via)+finder.distances.distance(via, to) == finder.distances.distance(from, to)
+}
+
+// target returns where the pac playing role should move this turn: through its exit (unless it's already past it) and towards the prey,
+// closing the trap
+func (finder *TrapFinder) target(trap Trap, role TrapRole) Coord {
+	pos, prey := role.pac.pos, trap.prey.pos
+	if pos == role.exit || finder.passesBy(role.exit, prey, pos) || finder.passesBy(pos, prey, role.exit) {
+		return prey
+	}
+	return role.exit
+}
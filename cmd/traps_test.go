@@ -0,0 +1,112 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// figureEightMap has junctions at (3,3) and (5,3), joined by a one cell corridor, with a loop out of each
+const figureEightMap = `
+#########
+#   #   #
+# # # # #
+#       #
+#########`
+
+func TestTrapFinder(t *testing.T) {
+	tests := []struct {
+		name     string
+		cells    string
+		myPacs   []Pac
+		prey     Pac
+		expected []TrapRole
+	}{
+		{
+			name:     "prey in a dead end",
+			cells:    teeMap,
+			myPacs:   []Pac{{id: 0, mine: true, pos: Coord{4, 1}, typeID: "ROCK"}},
+			prey:     Pac{id: 0, pos: Coord{1, 1}, typeID: "SCISSORS", abilityCooldown: 5},
+			expected: []TrapRole{{Pac{id: 0, mine: true, pos: Coord{4, 1}, typeID: "ROCK"}, Coord{3, 1}, true}},
+		},
+		{
+			name:   "prey between two of my pacs",
+			cells:  figureEightMap,
+			myPacs: []Pac{{id: 0, mine: true, pos: Coord{2, 3}, typeID: "ROCK"}, {id: 1, mine: true, pos: Coord{6, 3}, typeID: "SCISSORS"}},
+			prey:   Pac{id: 0, pos: Coord{4, 3}, typeID: "SCISSORS", abilityCooldown: 5},
+			expected: []TrapRole{
+				{Pac{id: 0, mine: true, pos: Coord{2, 3}, typeID: "ROCK"}, Coord{3, 3}, true},
+				{Pac{id: 1, mine: true, pos: Coord{6, 3}, typeID: "SCISSORS"}, Coord{5, 3}, false},
+			},
+		},
+		{
+			name:   "prey that can get out first",
+			cells:  teeMap,
+			myPacs: []Pac{{id: 0, mine: true, pos: Coord{3, 3}, typeID: "ROCK"}},
+			prey:   Pac{id: 0, pos: Coord{2, 1}, typeID: "SCISSORS", abilityCooldown: 5},
+		},
+		{
+			name:   "prey that can switch before it's caught",
+			cells:  teeMap,
+			myPacs: []Pac{{id: 0, mine: true, pos: Coord{4, 1}, typeID: "ROCK"}},
+			prey:   Pac{id: 0, pos: Coord{1, 1}, typeID: "SCISSORS", abilityCooldown: 2},
+		},
+		{
+			name:   "prey between my pac and the way out",
+			cells:  teeMap,
+			myPacs: []Pac{{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}},
+			prey:   Pac{id: 0, pos: Coord{2, 1}, typeID: "SCISSORS", abilityCooldown: 5},
+		},
+		{
+			name:   "prey that's already dead",
+			cells:  teeMap,
+			myPacs: []Pac{{id: 0, mine: true, pos: Coord{4, 1}, typeID: "ROCK"}},
+			prey:   Pac{id: 0, pos: Coord{1, 1}, typeID: deadTypeID},
+		},
+		{
+			name:   "dead pacs don't close in",
+			cells:  teeMap,
+			myPacs: []Pac{{id: 0, mine: true, pos: Coord{4, 1}, typeID: deadTypeID}},
+			prey:   Pac{id: 0, pos: Coord{1, 1}, typeID: "SCISSORS", abilityCooldown: 5},
+		},
+		{
+			name:   "prey that eats the only pac closing in",
+			cells:  teeMap,
+			myPacs: []Pac{{id: 0, mine: true, pos: Coord{4, 1}, typeID: "PAPER"}},
+			prey:   Pac{id: 0, pos: Coord{1, 1}, typeID: "SCISSORS", abilityCooldown: 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gameMap := BuildGameMap(tt.cells)
+			finder := newTrapFinder(gameMap, newDistanceTable(gameMap), newMazeGraph(gameMap))
+
+			var roles []TrapRole
+			for _, trap := range finder.find(tt.myPacs, []Pac{tt.prey}) {
+				roles = append(roles, trap.roles...)
+			}
+			sort.Slice(roles, func(i, j int) bool { return roles[i].pac.id < roles[j].pac.id })
+			if !reflect.DeepEqual(tt.expected, roles) {
+				t.Errorf("expected roles %+v, but got %+v", tt.expected, roles)
+			}
+		})
+	}
+}
+
+func TestTrapFinderClosesIn(t *testing.T) {
+	gameMap := BuildGameMap(figureEightMap)
+	finder := newTrapFinder(gameMap, newDistanceTable(gameMap), newMazeGraph(gameMap))
+	prey := Pac{id: 0, pos: Coord{4, 3}, typeID: "SCISSORS", abilityCooldown: 5}
+	guards := []Pac{{id: 0, mine: true, pos: Coord{2, 3}, typeID: "ROCK"}, {id: 1, mine: true, pos: Coord{6, 3}, typeID: "SCISSORS"}}
+
+	if expected, actual := []Coord{{4, 3}}, finder.escapeSet(prey, guards); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected the prey to only be safe on %v, but got %v", expected, actual)
+	}
+	// both the chaser and the blocker walk through their exit on their way to the prey
+	trap := Trap{prey, []TrapRole{{guards[0], Coord{3, 3}, true}, {guards[1], Coord{5, 3}, false}}}
+	for _, role := range trap.roles {
+		if expected, actual := prey.pos, finder.target(trap, role); expected != actual {
+			t.Errorf("expected pac %v to go for %v, but got %v", role.pac.id, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------------
+// Viewer: steps through a recorded game in the terminal
+//-----------------------------------------------------------------------------------
+
+// viewerFrame is one turn of a recorded game, as displayed by the viewer
+type viewerFrame struct {
+	// gameData is the fogged input the bot was given on this turn
+	gameData GameData
+	command  string
+	// state is the true state of the game, or nil if the game was recorded by the bot itself
+	state *ReplayState
+	// believedPellets contains the value of the pellet the bot believes each cell most likely holds once it has seen this turn, indexed by
+	// absolute position
+	believedPellets []int
+}
+
+// view lets the user browse the game log or replay file at path from the terminal. player selects whose point of view is shown in a replay
+func view(path string, player int) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	frames, err := loadViewerFrames(content, player)
+	if err != nil {
+		return fmt.Errorf("%v: %v", path, err)
+	}
+	runViewer(os.Stdin, os.Stdout, frames, true)
+	return nil
+}
+
+// loadViewerFrames parses either a replay file or a game log, as written by main with -replay or -log
+func loadViewerFrames(content []byte, player int) ([]viewerFrame, error) {
+	var frames []viewerFrame
+	var final *ReplayState
+	if trimmed := bytes.TrimSpace(content); len(trimmed) > 0 && trimmed[0] == '{' {
+		replay, err := readReplay(bytes.NewReader(content))
+		if err != nil {
+			return nil, err
+		}
+		for turn, replayTurn := range replay.turns {
+			if player >= len(replayTurn.players) {
+				return nil, fmt.Errorf("player %v didn't play turn %v", player, turn)
+			}
+			playerTurn := replayTurn.players[player]
+			frames = append(frames, viewerFrame{gameData: playerTurn.gameData, command: playerTurn.command, state: replayTurn.state})
+		}
+		final = replay.final
+	} else {
+		turns, commands, err := readGameLog(bytes.NewReader(content))
+		if err != nil {
+			return nil, err
+		}
+		for turn, gameData := range turns {
+			frames = append(frames, viewerFrame{gameData: gameData, command: commands[turn]})
+		}
+	}
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("no turns were recorded")
+	}
+
+	// replay the bot's memory to show what it believed on each turn
+	bot := DansLilHeuristicBot{}
+	bot.init(frames[0].gameData.gameMap)
+	for i := range frames {
+		bot.update(frames[i].gameData)
+		frames[i].believedPellets = bot.pellets.likelyValues()
+	}
+
+	if final != nil {
+		// the game ends with nothing new for the bot to see, only the outcome of its last command
+		closing := frames[len(frames)-1]
+		closing.gameData.round++
+		closing.gameData.scores = []int{final.scores[player], final.scores[1-player]}
+		closing.command, closing.state = "", final
+		frames = append(frames, closing)
+	}
+	return frames, nil
+}
+
+// readGameLog parses a game log: the initialization block, then for each turn the input main read followed by the command it printed.
+// A last turn without a command (the game stopped while the bot was thinking) is dropped.
+func readGameLog(r io.Reader) ([]GameData, []string, error) {
+	var turns []GameData
+	var commands []string
+	reader := newProtocolReader(r)
+	if _, err := reader.readInit(); err != nil {
+		return nil, nil, err
+	}
+	for {
+		gameData, err := reader.readTurn()
+		if err == io.EOF {
+			return turns, commands, nil
+		} else if err != nil {
+			return nil, nil, err
+		}
+		command, err := reader.readCommand()
+		if err == io.EOF {
+			return turns, commands, nil
+		} else if err != nil {
+			return nil, nil, err
+		}
+		turns = append(turns, gameData)
+		commands = append(commands, command)
+	}
+}
+
+const (
+	ansiReset       = "\033[0m"
+	ansiClearScreen = "\033[H\033[2J"
+	ansiInSight     = "\033[44m"
+	ansiMine        = "\033[1;32m"
+	ansiEnemy       = "\033[1;31m"
+	ansiBelieved    = "\033[2m"
+)
+
+// renderCell returns the two characters representing a cell: "##" for a wall, a type initial and an id for a pac (uppercase for mine,
+// lowercase for the enemy's), "o" for a super pellet and "." for a pellet. Without colors, cells in sight of the bot's pacs are padded
+// with "_" instead of " ", and pellets the bot only remembers are shown as ",".
+func renderCell(cell Cell, pac *Pac, pelletValue int, believed, inSight, color bool) string {
+	if cell.value == '#' {
+		return "##"
+	}
+	padding, text := " ", " "
+	if inSight && !color {
+		padding, text = "_", "_"
+	}
+	switch {
+	case pac != nil:
+		label := string(pac.typeID[:1]) + strconv.Itoa(pac.id)
+		if !pac.mine {
+			label = strings.ToLower(label)
+		}
+		if len(label) > 2 {
+			label = label[:2]
+		}
+		if color {
+			tint := ansiEnemy
+			if pac.mine {
+				tint = ansiMine
+			}
+			label = tint + label + ansiReset
+		}
+		return withBackground(label, inSight, color)
+	case pelletValue >= superPelletValue:
+		text = "o"
+	case pelletValue > 0 && believed:
+		text = ","
+		if color {
+			text = ansiBelieved + "." + ansiReset
+		}
+	case pelletValue > 0:
+		text = "."
+	}
+	return withBackground(padding+text, inSight, color)
+}
+
+func withBackground(text string, inSight, color bool) string {
+	if inSight && color {
+		return ansiInSight + text + ansiReset
+	}
+	return text
+}
+
+// renderFrame draws a turn: what the bot saw (and remembered) on the left, and the true state of the game on the right when it's known
+func renderFrame(w io.Writer, frames []viewerFrame, index int, color bool) {
+	frame := frames[index]
+	gm := frame.gameData.gameMap
+
+	inSight := make(map[Coord]bool)
+	seenPacs := make(map[Coord]*Pac)
+	for i, pac := range frame.gameData.visiblePacs {
+		if pac.typeID == deadTypeID {
+			continue
+		}
+		seenPacs[pac.pos] = &frame.gameData.visiblePacs[i]
+		if pac.mine {
+			for _, coord := range gm.VisibleCells(pac.pos) {
+				inSight[coord] = true
+			}
+		}
+	}
+	seenPellets := make(map[Coord]int)
+	for _, pellet := range frame.gameData.visiblePellets {
+		seenPellets[pellet.pos] = pellet.value
+	}
+	truePacs := make(map[Coord]*Pac)
+	truePellets := make(map[Coord]int)
+	if frame.state != nil {
+		for i, pac := range frame.state.pacs {
+			if pac.typeID != deadTypeID {
+				truePacs[pac.pos] = &frame.state.pacs[i]
+			}
+		}
+		for _, pellet := range frame.state.pellets {
+			truePellets[pellet.pos] = pellet.value
+		}
+	}
+
+	fmt.Fprintf(w, "turn %v/%v    score %v - %v\n", index+1, len(frames), frame.gameData.scores[0], frame.gameData.scores[1])
+	header := fmt.Sprintf("%-*v", gm.width*2, "seen by the bot")
+	if frame.state != nil {
+		header += "    true state"
+	}
+	fmt.Fprintln(w, header)
+	for y := 0; y < gm.height; y++ {
+		var line strings.Builder
+		for x := 0; x < gm.width; x++ {
+			pos := Coord{x, y}
+			value, seen := seenPellets[pos]
+			if !seen && !inSight[pos] {
+				value = frame.believedPellets[gm.GetAbsolutePosition(pos)]
+			}
+			line.WriteString(renderCell(gm.GetCell(pos), seenPacs[pos], value, !seen, inSight[pos], color))
+		}
+		if frame.state != nil {
+			line.WriteString("    ")
+			for x := 0; x < gm.width; x++ {
+				pos := Coord{x, y}
+				line.WriteString(renderCell(gm.GetCell(pos), truePacs[pos], truePellets[pos], false, false, color))
+			}
+		}
+		fmt.Fprintln(w, line.String())
+	}
+	fmt.Fprintln(w, "commands:")
+	for _, command := range strings.Split(frame.command, "|") {
+		fmt.Fprintln(w, "  "+strings.TrimSpace(command))
+	}
+}
+
+// runViewer shows frames one at a time, reading navigation commands from in: enter or "n" for the next turn, "p" for the previous one,
+// a turn number (or "g" and a turn number) to jump to it, and "q" to quit
+func runViewer(in io.Reader, out io.Writer, frames []viewerFrame, color bool) {
+	scanner := bufio.NewScanner(in)
+	index := 0
+	for {
+		if color {
+			fmt.Fprint(out, ansiClearScreen)
+		}
+		renderFrame(out, frames, index, color)
+		fmt.Fprint(out, "[enter/n]ext, [p]rev, [g]oto <turn>, [q]uit > ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return
+		}
+
+		input := strings.Fields(scanner.Text())
+		if len(input) > 0 && input[0] == "g" {
+			input = input[1:]
+		}
+		switch {
+		case len(input) == 0 || input[0] == "n":
+			if index < len(frames)-1 {
+				index++
+			}
+		case input[0] == "p":
+			if index > 0 {
+				index--
+			}
+		case input[0] == "q":
+			return
+		default:
+			if turn, err := strconv.Atoi(input[0]); err == nil && turn >= 1 && turn <= len(frames) {
+				index = turn - 1
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const corridorGameLog = `7 3
+#######
+#     #
+#######
+0 0
+2
+0 1 1 1 ROCK 0 0
+0 0 4 1 SCISSORS 0 0
+2
+2 1 1
+3 1 10
+MOVE 0 4 1 hi|SPEED 1
+1 0
+1
+0 1 2 1 ROCK 0 0
+1
+3 1 10
+MOVE 0 4 1
+`
+
+func TestReadGameLog(t *testing.T) {
+	turns, commands, err := readGameLog(strings.NewReader(corridorGameLog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"MOVE 0 4 1 hi|SPEED 1", "MOVE 0 4 1"}; !reflect.DeepEqual(expected, commands) {
+		t.Errorf("expected commands %q, but got %q", expected, commands)
+	}
+	if len(turns) != 2 {
+		t.Fatalf("expected 2 turns, but got %v", len(turns))
+	}
+	expected := GameData{
+		round:          1,
+		gameMap:        BuildGameMap(corridorMap),
+		scores:         []int{1, 0},
+		visiblePacs:    []Pac{{id: 0, mine: true, pos: Coord{2, 1}, typeID: "ROCK"}},
+		visiblePellets: []Pellet{{Coord{3, 1}, 10}},
+	}
+	if actual := turns[1]; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected %+v, but got %+v", expected, actual)
+	}
+}
+
+func TestReadGameLogErrors(t *testing.T) {
+	if _, _, err := readGameLog(strings.NewReader("7 3\n#######\n")); err == nil {
+		t.Errorf("expected an error for a truncated map")
+	}
+	if _, _, err := readGameLog(strings.NewReader(strings.Replace(corridorGameLog, "0 1 2 1 ROCK", "0 1 x 1 ROCK", 1))); err == nil || !strings.Contains(err.Error(), "line 15") {
+		t.Errorf("expected an error on line 15, but got %v", err)
+	}
+
+	turns, _, err := readGameLog(strings.NewReader(strings.TrimSuffix(corridorGameLog, "MOVE 0 4 1\n")))
+	if err != nil || len(turns) != 1 {
+		t.Errorf("expected a turn without a command to be dropped, but got %v turns and error %v", len(turns), err)
+	}
+}
+
+func TestRenderFrame(t *testing.T) {
+	frames, err := loadViewerFrames([]byte(corridorGameLog), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	renderFrame(&out, frames, 1, false)
+
+	// on turn 2, the bot's pac at (2,1) sees the whole corridor: the pellet at (2,1) was eaten, the super pellet is still there, and the
+	// enemy has gone out of sight
+	expected := `turn 2/2    score 1 - 0
+seen by the bot
+##############
+##__R0_o____##
+##############
+commands:
+  MOVE 0 4 1
+`
+	if actual := out.String(); expected != actual {
+		t.Errorf("expected\n%v\nbut got\n%v", expected, actual)
+	}
+}
+
+func TestRenderFrameWithTrueState(t *testing.T) {
+	frames := []viewerFrame{{
+		gameData: GameData{gameMap: BuildGameMap(corridorMap), scores: []int{0, 0}, visiblePacs: []Pac{{id: 0, mine: true, pos: Coord{1, 1}, typeID: "PAPER"}}},
+		state: &ReplayState{
+			pacs:    []Pac{{id: 0, mine: true, pos: Coord{1, 1}, typeID: "PAPER"}, {id: 1, pos: Coord{5, 1}, typeID: "ROCK"}},
+			pellets: []Pellet{{Coord{3, 1}, 1}},
+		},
+		believedPellets: make([]int, 21),
+	}}
+
+	var out bytes.Buffer
+	renderFrame(&out, frames, 0, false)
+
+	if expected := "##P0________##    ##P0   .  r1##"; !strings.Contains(out.String(), expected) {
+		t.Errorf("expected the bot's view next to the true state %q, but got\n%v", expected, out.String())
+	}
+}
+
+func TestLoadViewerFramesShowsHowTheGameEnded(t *testing.T) {
+	gm := BuildGameMap(corridorMap)
+	var file bytes.Buffer
+	recorder, err := newReplayRecorder(&file, gm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pac := Pac{id: 0, mine: true, pos: Coord{1, 1}, typeID: "ROCK"}
+	gameData := GameData{0, gm, []int{0, 0}, []Pac{pac}, []Pellet{{Coord{2, 1}, 1}}}
+	state := &ReplayState{pacs: []Pac{pac}, pellets: gameData.visiblePellets}
+	recorder.recordTurn(ReplayTurn{0, state, []ReplayPlayerTurn{{gameData, "MOVE 0 2 1", ""}}})
+	pac.pos = Coord{2, 1}
+	final := &ReplayState{pacs: []Pac{pac}, scores: [2]int{1, 0}}
+	recorder.recordTurn(ReplayTurn{turn: 1, state: final})
+
+	frames, err := loadViewerFrames(file.Bytes(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 2 || frames[1].state == nil || !reflect.DeepEqual(final.pacs, frames[1].state.pacs) {
+		t.Fatalf("expected a closing frame with the final state %+v, but got %+v", final, frames)
+	}
+	var out bytes.Buffer
+	renderFrame(&out, frames, 1, false)
+	if expected := "turn 2/2    score 1 - 0"; !strings.Contains(out.String(), expected) {
+		t.Errorf("expected the final scores %q, but got\n%v", expected, out.String())
+	}
+}
+
+func TestRunViewerNavigation(t *testing.T) {
+	frames, err := loadViewerFrames([]byte(corridorGameLog), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	runViewer(strings.NewReader("\nn\np\ng 2\n1\nq\nn\n"), &out, frames, false)
+
+	var shown []string
+	for _, line := range strings.Split(out.String(), "\n") {
+		if strings.Contains(line, "turn ") {
+			shown = append(shown, strings.Fields(line[strings.Index(line, "turn "):])[1])
+		}
+	}
+	if expected := []string{"1/2", "2/2", "2/2", "1/2", "2/2", "1/2"}; !reflect.DeepEqual(expected, shown) {
+		t.Errorf("expected turns %v to be shown, but got %v", expected, shown)
+	}
+}